@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"wled-simulator/internal/bench"
+)
+
+// runBench implements the bench subcommand, streaming DDP frames to a running
+// simulator and reporting the achieved frame rate
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	host := fs.String("host", "127.0.0.1", "Simulator host to send DDP frames to")
+	port := fs.Int("port", 4048, "Simulator DDP port")
+	fps := fs.Int("fps", 60, "Target frames per second")
+	duration := fs.Duration("duration", 10*time.Second, "How long to stream frames")
+	leds := fs.Int("leds", 300, "Pixels per frame")
+	fs.Parse(args)
+
+	// Stop early on Ctrl+C and still report what was sent
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	addr := net.JoinHostPort(*host, strconv.Itoa(*port))
+	fmt.Printf("Streaming %d LEDs to %s at %d fps for %v...\n", *leds, addr, *fps, *duration)
+
+	result, err := bench.Run(ctx, bench.Options{
+		Addr:     addr,
+		FPS:      *fps,
+		Duration: *duration,
+		LEDs:     *leds,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Sent %d frames (%d packets) in %v: %.1f fps\n",
+		result.Frames, result.Packets, result.Elapsed.Round(time.Millisecond), result.FPS())
+	if result.Errors > 0 {
+		fmt.Printf("%d packets failed, last error: %v\n", result.Errors, result.LastError)
+		os.Exit(1)
+	}
+}
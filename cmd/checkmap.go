@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"wled-simulator/internal/ledmap"
+)
+
+// runCheckmap implements the checkmap subcommand, validating a ledmap file
+// against the LED count without starting the simulator
+func runCheckmap(args []string) {
+	fs := flag.NewFlagSet("checkmap", flag.ExitOnError)
+	path := fs.String("ledmap", "", "WLED ledmap.json file to check")
+	count := fs.Int("count", 0, "Number of LEDs the map must cover")
+	fs.Parse(args)
+
+	if *path == "" || *count < 1 {
+		log.Fatal("checkmap requires -ledmap and a positive -count")
+	}
+
+	m, err := ledmap.Load(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	problems := ledmap.Check(m, *count)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%s: %d problems\n", *path, len(problems))
+		os.Exit(1)
+	}
+	fmt.Printf("%s: valid map of %d LEDs\n", *path, *count)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile overlays the settings in the YAML file at path onto cfg.
+// Each key is decoded on its own so a mistyped value is reported with its
+// field name and line, rather than failing the whole file. Unknown keys are
+// ignored. A missing file returns an error wrapping os.ErrNotExist.
+func loadConfigFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return applyConfig(path, data, cfg)
+}
+
+// configURLTimeout bounds fetching a remote config, so an unreachable server
+// delays startup by at most this long
+const configURLTimeout = 5 * time.Second
+
+// maxConfigSize limits how much of a remote config response is read
+const maxConfigSize = 1 << 20
+
+// loadConfigURL overlays the settings in the YAML served at url onto cfg,
+// like loadConfigFile. cfg is left unchanged if the request fails, times out,
+// returns a non-2xx status or has an invalid value.
+func loadConfigURL(url string, timeout time.Duration, cfg *Config) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to fetch config from %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize))
+	if err != nil {
+		return fmt.Errorf("failed to fetch config from %s: %v", url, err)
+	}
+
+	remote := *cfg
+	if err := applyConfig(url, data, &remote); err != nil {
+		return err
+	}
+	*cfg = remote
+	return nil
+}
+
+// applyConfig overlays the YAML settings in data onto cfg. path is the file
+// or URL data was read from, for error messages.
+func applyConfig(path string, data []byte, cfg *Config) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("invalid YAML in %s: %v", path, err)
+	}
+	if len(root.Content) == 0 {
+		return nil // Empty file
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s line %d: expected a mapping of settings", path, doc.Line)
+	}
+
+	// Index the config fields by their yaml key
+	fields := make(map[string]reflect.Value)
+	cfgValue := reflect.ValueOf(cfg).Elem()
+	cfgType := cfgValue.Type()
+	for i := 0; i < cfgType.NumField(); i++ {
+		if key := cfgType.Field(i).Tag.Get("yaml"); key != "" {
+			fields[key] = cfgValue.Field(i)
+		}
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+		field, ok := fields[key.Value]
+		if !ok {
+			continue
+		}
+		if err := value.Decode(field.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s line %d: invalid value %q for '%s', expected %s",
+				path, value.Line, value.Value, key.Value, describeType(field.Type()))
+		}
+	}
+	return nil
+}
+
+// dumpConfigFile writes cfg to path as YAML, using the same keys that
+// loadConfigFile reads
+func dumpConfigFile(path string, cfg Config) error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// describeType names a config field type for error messages
+func describeType(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		return "a duration such as 30s"
+	case t.Kind() == reflect.Int:
+		return "an integer"
+	case t.Kind() == reflect.Float64:
+		return "a number"
+	case t.Kind() == reflect.Bool:
+		return "true or false"
+	case t.Kind() == reflect.String:
+		return "a string"
+	default:
+		return t.String()
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfig(t, "rows: 16\ncols: 8\nwiring: col\njitter_buffer: 33ms\nunknown_key: 1\n")
+
+	cfg := Config{Rows: 10, Cols: 2, Name: "kept"}
+	if err := loadConfigFile(path, &cfg); err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	if cfg.Rows != 16 || cfg.Cols != 8 || cfg.Wiring != "col" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.JitterBuffer != 33*time.Millisecond {
+		t.Errorf("JitterBuffer = %v, want 33ms", cfg.JitterBuffer)
+	}
+	if cfg.Name != "kept" {
+		t.Errorf("Name = %q, want values absent from the file kept", cfg.Name)
+	}
+}
+
+func TestLoadConfigFileTypeError(t *testing.T) {
+	path := writeConfig(t, "cols: 2\nrows: \"ten\"\n")
+
+	var cfg Config
+	err := loadConfigFile(path, &cfg)
+	if err == nil {
+		t.Fatal("expected an error for a mistyped field")
+	}
+	for _, want := range []string{"line 2", `"ten"`, "'rows'", "an integer"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadConfigFileMissing(t *testing.T) {
+	var cfg Config
+	err := loadConfigFile(filepath.Join(t.TempDir(), "missing.yaml"), &cfg)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestDumpConfigFileRoundTrip(t *testing.T) {
+	want := Config{
+		Rows:         16,
+		Cols:         8,
+		Wiring:       "col",
+		Panels:       2,
+		HTTPAddress:  "127.0.0.1:9090",
+		InitColor:    "#202020",
+		Controls:     true,
+		JitterBuffer: 33 * time.Millisecond,
+		LiveDebounce: 50 * time.Millisecond,
+		ColorOrder:   "GRB",
+	}
+	path := filepath.Join(t.TempDir(), "dump.yaml")
+	if err := dumpConfigFile(path, want); err != nil {
+		t.Fatalf("dumpConfigFile failed: %v", err)
+	}
+
+	var got Config
+	if err := loadConfigFile(path, &got); err != nil {
+		t.Fatalf("loadConfigFile failed: %v", err)
+	}
+	if got != want {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "jitter_buffer: 33ms") {
+		t.Errorf("expected durations written in readable form, got:\n%s", data)
+	}
+}
+
+func TestLoadConfigURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "rows: 16\nwiring: col\nidle_exit: 30s\n")
+	}))
+	defer srv.Close()
+
+	cfg := Config{Rows: 10, Cols: 2}
+	if err := loadConfigURL(srv.URL, time.Second, &cfg); err != nil {
+		t.Fatalf("loadConfigURL failed: %v", err)
+	}
+	if cfg.Rows != 16 || cfg.Wiring != "col" || cfg.IdleExit != 30*time.Second {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Cols != 2 {
+		t.Errorf("Cols = %d, want values absent from the response kept", cfg.Cols)
+	}
+}
+
+func TestLoadConfigURLFailureKeepsConfig(t *testing.T) {
+	slow := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/invalid":
+			fmt.Fprint(w, "rows: 16\ncols: many\n")
+		case "/slow":
+			<-slow
+		}
+	}))
+	defer srv.Close()
+	defer close(slow)
+
+	for _, path := range []string{"/missing", "/invalid", "/slow"} {
+		t.Run(path, func(t *testing.T) {
+			cfg := Config{Rows: 10, Cols: 2}
+			if err := loadConfigURL(srv.URL+path, 50*time.Millisecond, &cfg); err == nil {
+				t.Error("expected an error")
+			}
+			if cfg.Rows != 10 || cfg.Cols != 2 {
+				t.Errorf("config changed to %+v after a failed fetch", cfg)
+			}
+		})
+	}
+}
@@ -1,65 +1,192 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"log"
-	"net/http"
+	"net"
 	"os"
 	"os/signal"
 	"reflect"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"wled-simulator/internal/api"
+	"wled-simulator/internal/ascii"
 	"wled-simulator/internal/ddp"
 	"wled-simulator/internal/gui"
+	"wled-simulator/internal/imageframe"
+	"wled-simulator/internal/ledmap"
+	"wled-simulator/internal/shm"
 	"wled-simulator/internal/state"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
-	"gopkg.in/yaml.v3"
 )
 
 // Config holds application configuration
 type Config struct {
-	Rows        int    `yaml:"rows" flag:"rows"`
-	Cols        int    `yaml:"cols" flag:"cols"`
-	Wiring      string `yaml:"wiring" flag:"wiring"`
-	HTTPAddress string `yaml:"http_address" flag:"http"`
-	DDPPort     int    `yaml:"ddp_port" flag:"ddp-port"`
-	InitColor   string `yaml:"init_color" flag:"init"`
-	Name        string `yaml:"name" flag:"name"`
-	Controls    bool   `yaml:"controls" flag:"controls"`
-	Headless    bool   `yaml:"headless" flag:"headless"`
-	Verbose     bool   `yaml:"verbose" flag:"v"`
+	Rows         int           `yaml:"rows" flag:"rows"`
+	Cols         int           `yaml:"cols" flag:"cols"`
+	Wiring       string        `yaml:"wiring" flag:"wiring"`
+	Panels       int           `yaml:"panels" flag:"panels"`
+	Layout       string        `yaml:"layout" flag:"layout"`
+	RingRadius   float64       `yaml:"ring_radius" flag:"ring-radius"`
+	MinWindow    string        `yaml:"min_window" flag:"min-window"`
+	HTTPAddress  string        `yaml:"http_address" flag:"http"`
+	DDPPort      int           `yaml:"ddp_port" flag:"ddp-port"`
+	InitColor    string        `yaml:"init_color" flag:"init"`
+	InitImage    string        `yaml:"init_image" flag:"init-image"`
+	PlayImage    string        `yaml:"play_image" flag:"play-image"`
+	PlayFPS      int           `yaml:"play_fps" flag:"play-fps"`
+	Name         string        `yaml:"name" flag:"name"`
+	Controls     bool          `yaml:"controls" flag:"controls"`
+	Headless     bool          `yaml:"headless" flag:"headless"`
+	Verbose      bool          `yaml:"verbose" flag:"v"`
+	JitterBuffer time.Duration `yaml:"jitter_buffer" flag:"jitter-buffer"`
+	FWVersion    string        `yaml:"fw_version" flag:"fw-version"`
+	FWVid        int           `yaml:"fw_vid" flag:"fw-vid"`
+	ResetReason  string        `yaml:"reset_reason" flag:"reset-reason"`
+	Interface    string        `yaml:"interface" flag:"interface"`
+	AdvertiseIP  string        `yaml:"advertise_ip" flag:"advertise-ip"`
+	LeadTo       string        `yaml:"lead_to" flag:"lead-to"`
+	ActivityBuf  int           `yaml:"activity_buffer" flag:"activity-buffer"`
+	BPP          int           `yaml:"bpp" flag:"bpp"`
+	Overflow     string        `yaml:"overflow" flag:"overflow"`
+	ColorOrder   string        `yaml:"color_order" flag:"color-order"`
+	DDPEndian    string        `yaml:"ddp_endian" flag:"ddp-endian"`
+	WhiteOverlay bool          `yaml:"white_overlay" flag:"white-overlay"`
+	RGBW         bool          `yaml:"rgbw" flag:"rgbw"`
+	NoHTTP       bool          `yaml:"no_http" flag:"no-http"`
+	NoDDP        bool          `yaml:"no_ddp" flag:"no-ddp"`
+	LenientVer   bool          `yaml:"lenient_version" flag:"lenient-version"`
+	DeviceSegMap string        `yaml:"device_segment_map" flag:"device-segment-map"`
+	LEDMapDir    string        `yaml:"ledmap_dir" flag:"ledmap-dir"`
+	History      int           `yaml:"history" flag:"history"`
+	PreviewPort  int           `yaml:"preview_port" flag:"preview-port"`
+	LiveDebounce time.Duration `yaml:"live_debounce" flag:"live-debounce"`
+	ChannelMA    int           `yaml:"channel_ma" flag:"channel-ma"`
+	BaseMA       int           `yaml:"base_ma" flag:"base-ma"`
+	BlackoutIdle bool          `yaml:"blackout_on_idle" flag:"blackout-on-idle"`
+	BlackoutWipe bool          `yaml:"blackout_wipe" flag:"blackout-wipe"`
+	AccessLog    string        `yaml:"access_log" flag:"access-log"`
+	HTTPLatency  time.Duration `yaml:"http_latency" flag:"http-latency"`
+	RenderChunk  int           `yaml:"render_chunk" flag:"render-chunk"`
+	RenderStats  time.Duration `yaml:"render_stats" flag:"render-stats"`
+	IdleExit     time.Duration `yaml:"idle_exit" flag:"idle-exit"`
+	ShowRaw      bool          `yaml:"show_raw" flag:"show-raw"`
+	AgeView      bool          `yaml:"age_view" flag:"age-view"`
+	ChannelCap   int           `yaml:"channel_cap" flag:"channel-cap"`
+	Dither       bool          `yaml:"dither" flag:"dither"`
+	StateFile    string        `yaml:"state_file" flag:"state-file"`
+	StrictAlign  bool          `yaml:"strict_align" flag:"strict-align"`
+	DDPChecksum  bool          `yaml:"ddp_checksum" flag:"ddp-checksum"`
+	Wrap         bool          `yaml:"wrap" flag:"wrap"`
+	Calibration  string        `yaml:"calibration" flag:"calibration"`
+	Demo         bool          `yaml:"demo" flag:"demo"`
+	StdinDDP     bool          `yaml:"stdin_ddp" flag:"stdin-ddp"`
+	Shm          string        `yaml:"shm" flag:"shm"`
+	AsciiPreview time.Duration `yaml:"ascii_preview" flag:"ascii-preview"`
 }
 
 func main() {
+	// Subcommands
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "checkmap":
+			runCheckmap(os.Args[2:])
+			return
+		}
+	}
+
 	// Command line flags
 	var cfg Config
 	flag.IntVar(&cfg.Rows, "rows", 10, "Number of LED rows")
 	flag.IntVar(&cfg.Cols, "cols", 2, "Number of LED columns")
 	flag.StringVar(&cfg.Wiring, "wiring", "row", "LED wiring pattern: 'row' (row-major) or 'col' (column-major)")
+	flag.StringVar(&cfg.Layout, "layout", "grid", "GUI LED layout: 'grid' (rows x cols) or 'ring' (evenly around a circle in wiring order)")
+	flag.StringVar(&cfg.MinWindow, "min-window", "200x150", "Smallest GUI window size as WIDTHxHEIGHT, scaled on HiDPI displays")
+	flag.Float64Var(&cfg.RingRadius, "ring-radius", 0.9, "With -layout ring, the ring radius as a fraction of the window (0-1]")
+	flag.IntVar(&cfg.Panels, "panels", 1, "Number of rows x cols panels, each shown in its own window and driven by the next range of LEDs")
 	flag.StringVar(&cfg.HTTPAddress, "http", ":8080", "HTTP listen address")
+	flag.BoolVar(&cfg.NoHTTP, "no-http", false, "Don't start the JSON API, leaving DDP and the GUI running")
 	flag.IntVar(&cfg.DDPPort, "ddp-port", 4048, "UDP port for DDP")
+	flag.BoolVar(&cfg.NoDDP, "no-ddp", false, "Don't start the DDP listener, leaving the JSON API and GUI running")
 	flag.StringVar(&cfg.InitColor, "init", "#000000", "Initial color hex")
+	flag.StringVar(&cfg.PlayImage, "play-image", "", "GIF or directory of PNGs looped onto each panel until DDP goes live")
+	flag.IntVar(&cfg.PlayFPS, "play-fps", 10, "Frames per second for a -play-image directory")
+	flag.StringVar(&cfg.InitImage, "init-image", "", "PNG image scaled onto each panel at startup, falling back to -init on error")
 	flag.StringVar(&cfg.Name, "name", "", "Display name for the LED matrix")
 	flag.BoolVar(&cfg.Controls, "controls", false, "Show power/brightness controls in GUI")
 	flag.BoolVar(&cfg.Headless, "headless", false, "Run without GUI")
 	flag.BoolVar(&cfg.Verbose, "v", false, "Verbose logging")
+	flag.StringVar(&cfg.FWVersion, "fw-version", api.DefaultVersion, "Firmware version reported in /json/info (ver)")
+	flag.StringVar(&cfg.ResetReason, "reset-reason", api.DefaultResetReason, "Reset reason reported as info.resetReason")
+	flag.IntVar(&cfg.FWVid, "fw-vid", 0, "Firmware build number reported in /json/info (vid), 0 omits it")
+	flag.StringVar(&cfg.Interface, "interface", "", "Bind HTTP and DDP to this local IP only (default all interfaces)")
+	flag.StringVar(&cfg.AdvertiseIP, "advertise-ip", "", "IP address reported in /json/info (default: detect the primary outbound address)")
+	flag.IntVar(&cfg.ActivityBuf, "activity-buffer", state.DefaultActivityBuffer, "Activity events queued for the GUI before new ones are dropped")
+	flag.StringVar(&cfg.LeadTo, "lead-to", "", "Comma-separated follower base URLs to forward each POST /json/state to (e.g. http://host:8080)")
+	flag.IntVar(&cfg.BPP, "bpp", 3, "DDP bytes per pixel: 3 (RGB) or 4 (RGBW, white added into RGB)")
+	flag.StringVar(&cfg.Overflow, "overflow", "clamp", "Channel overflow behavior: 'clamp' or 'wrap'")
+	flag.BoolVar(&cfg.LenientVer, "lenient-version", false, "Accept DDP packets with version bits 0 as version 1, for older senders")
+	flag.StringVar(&cfg.LEDMapDir, "ledmap-dir", "", "Directory of WLED ledmap0.json to ledmap9.json files; DDP pixels go through map 0, switchable with {\"ledmap\":n}")
+	flag.StringVar(&cfg.DeviceSegMap, "device-segment-map", "", "Route DDP device IDs to segments as device=segment pairs (e.g. 2=1,3=2)")
+	flag.BoolVar(&cfg.WhiteOverlay, "white-overlay", false, "With -bpp 4, keep the white channel separate and show it as a dot on each LED instead of adding it into RGB")
+	flag.BoolVar(&cfg.RGBW, "rgbw", false, "RGBW LEDs: read 4 byte DDP pixels, store white as its own channel, show it as a dot and report rgbw in /json/info")
+	flag.StringVar(&cfg.ColorOrder, "color-order", "RGB", "DDP pixel byte order, e.g. 'RGB' or 'GRB'")
+	flag.StringVar(&cfg.DDPEndian, "ddp-endian", "big", "Byte order of 16-bit DDP samples: 'big' (DDP spec) or 'little'")
+	flag.DurationVar(&cfg.LiveDebounce, "live-debounce", 50*time.Millisecond, "Min interval between live timestamp updates at high DDP packet rates (0 updates on every packet)")
+	flag.IntVar(&cfg.PreviewPort, "preview-port", 0, "Receive a second DDP stream on this port into a separate preview window, leaving the main output untouched (0 disables)")
+	flag.IntVar(&cfg.History, "history", 16, fmt.Sprintf("Committed DDP frames kept for /json/history (0 disables, max %d)", ddp.MaxHistory))
+	flag.IntVar(&cfg.ChannelMA, "channel-ma", api.DefaultChannelMA, "Estimated mA per colour channel at full intensity (info.leds.pwr)")
+	flag.IntVar(&cfg.BaseMA, "base-ma", 0, "Estimated constant controller draw in mA (info.leds.pwr)")
+	flag.BoolVar(&cfg.BlackoutIdle, "blackout-on-idle", false, "Blank the display when DDP live data stops")
+	flag.BoolVar(&cfg.BlackoutWipe, "blackout-wipe", false, "With -blackout-on-idle, also clear the stored LED buffer")
+	flag.DurationVar(&cfg.HTTPLatency, "http-latency", 0, "Delay every HTTP response except /health by this long to simulate a slow device (e.g. 500ms)")
+	flag.StringVar(&cfg.AccessLog, "access-log", "text", "HTTP access log format: 'text' or 'json'")
+	flag.DurationVar(&cfg.RenderStats, "render-stats", 0, "Log GUI rectangles refreshed per frame and average frame render time at this interval (0 disables)")
+	flag.IntVar(&cfg.RenderChunk, "render-chunk", 0, "Max LEDs redrawn per GUI frame, spreading large matrices over several frames (0 redraws all)")
+	flag.StringVar(&cfg.Shm, "shm", "", "Publish each committed DDP frame to this file, e.g. /dev/shm/wled-sim")
+	flag.BoolVar(&cfg.StdinDDP, "stdin-ddp", false, "Read length-prefixed DDP packets from stdin instead of UDP")
+	flag.BoolVar(&cfg.Demo, "demo", false, "Animate the LEDs and activity lights without real traffic, for screenshots")
+	flag.StringVar(&cfg.Calibration, "calibration", "", "JSON file of per-channel display LUTs: {\"r\":[256],\"g\":[256],\"b\":[256]}")
+	flag.BoolVar(&cfg.DDPChecksum, "ddp-checksum", false, "Require a trailing big-endian CRC-32 of the pixel data on every DDP payload (non-standard), dropping packets that fail")
+	flag.BoolVar(&cfg.StrictAlign, "strict-align", false, "Flag DDP packets whose payload is not a whole number of pixels as failed")
+	flag.BoolVar(&cfg.Wrap, "wrap", false, "Wrap DDP writes past the last LED around to the first instead of truncating")
+	flag.StringVar(&cfg.StateFile, "state-file", "", "JSON file to persist named scenes in (empty keeps them in memory)")
+	flag.BoolVar(&cfg.Dither, "dither", false, "Ordered dither brightness scaling by LED position to smooth low brightness fades")
+	flag.IntVar(&cfg.ChannelCap, "channel-cap", 255, "Clamp every rendered channel to this maximum after brightness (255 disables)")
+	flag.BoolVar(&cfg.ShowRaw, "show-raw", false, "Start the GUI showing raw stored colors instead of rendered output")
+	flag.BoolVar(&cfg.AgeView, "age-view", false, "Color LEDs in the GUI by time since last write (white fresh, blue after 5s) to spot stuck pixels")
+	flag.DurationVar(&cfg.AsciiPreview, "ascii-preview", 0, "Print the displayed matrix as text to stdout at this interval when it changes (e.g. 1s, min 100ms, 0 disables)")
+	flag.DurationVar(&cfg.IdleExit, "idle-exit", 0, "Shut down after this long without DDP packets (e.g. 30s, 0 disables)")
+	flag.DurationVar(&cfg.JitterBuffer, "jitter-buffer", 0, "Buffer pushed DDP frames and release one per interval (e.g. 33ms, 0 disables)")
 
 	configFile := flag.String("config", "config.yaml", "Configuration file path")
+	configURL := flag.String("config-url", "", "Fetch YAML configuration from this HTTP URL at startup, overriding the config file")
+	dumpConfig := flag.String("dump-config", "", "Write the effective configuration to this YAML file and exit")
+	printMAC := flag.Bool("print-mac", false, "Print the MAC address /json/info would report for this config and exit")
 	flag.Parse()
 
 	// Save CLI values before loading config file
 	cliValues := cfg
 
-	// Load config file if it exists (this will overwrite cfg with file values)
-	if data, err := os.ReadFile(*configFile); err == nil {
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
-			log.Printf("Error parsing config file: %v", err)
+	// Load config file if it exists, then the remote config (these overwrite
+	// cfg with their values)
+	if err := loadConfigFile(*configFile, &cfg); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatalf("Error loading config file: %v", err)
+	}
+	if *configURL != "" {
+		if err := loadConfigURL(*configURL, configURLTimeout, &cfg); err != nil {
+			log.Printf("Ignoring remote config: %v", err)
 		}
 	}
 
@@ -80,16 +207,149 @@ func main() {
 		}
 	})
 
+	if *dumpConfig != "" {
+		if err := dumpConfigFile(*dumpConfig, cfg); err != nil {
+			log.Fatalf("Error writing config file: %v", err)
+		}
+		fmt.Printf("Configuration written to %s\n", *dumpConfig)
+		return
+	}
+
+	// Validate matrix size; an empty buffer leaves nothing to simulate
+	if cfg.Rows < 1 || cfg.Cols < 1 {
+		log.Fatalf("Invalid matrix size %dx%d. Rows and cols must both be at least 1", cfg.Rows, cfg.Cols)
+	}
+
+	if cfg.Panels < 1 {
+		log.Fatalf("Invalid panel count %d. Must be at least 1", cfg.Panels)
+	}
+
+	if cfg.ActivityBuf < 1 {
+		log.Fatalf("Invalid activity buffer %d. Must be at least 1", cfg.ActivityBuf)
+	}
+
 	// Validate wiring pattern
 	if cfg.Wiring != "row" && cfg.Wiring != "col" {
 		log.Fatalf("Invalid wiring pattern '%s'. Must be 'row' or 'col'", cfg.Wiring)
 	}
 
+	// Validate GUI layout
+	if cfg.Layout != "grid" && cfg.Layout != "ring" {
+		log.Fatalf("Invalid layout '%s'. Must be 'grid' or 'ring'", cfg.Layout)
+	}
+	if cfg.Layout == "ring" && (cfg.RingRadius <= 0 || cfg.RingRadius > 1) {
+		log.Fatalf("Invalid ring radius %g. Must be greater than 0 and at most 1", cfg.RingRadius)
+	}
+
+	// Validate minimum window size
+	var minWidth, minHeight int
+	if n, _ := fmt.Sscanf(cfg.MinWindow, "%dx%d", &minWidth, &minHeight); n != 2 || minWidth < 0 || minHeight < 0 {
+		log.Fatalf("Invalid minimum window size '%s'. Must be WIDTHxHEIGHT such as 200x150", cfg.MinWindow)
+	}
+	minWindow := fyne.NewSize(float32(minWidth), float32(minHeight))
+
+	// Validate overflow mode
+	overflow, err := ddp.ParseOverflowMode(cfg.Overflow)
+	if err != nil {
+		log.Fatal(err)
+	}
+	colorOrder, err := ddp.ParseColorOrder(cfg.ColorOrder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	endian, err := ddp.ParseEndian(cfg.DDPEndian)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deviceSegments, err := ddp.ParseDeviceSegmentMap(cfg.DeviceSegMap)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var ledMaps map[int][]int
+	if cfg.LEDMapDir != "" {
+		if ledMaps, err = ledmap.LoadSet(cfg.LEDMapDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Restrict both listeners to a single interface
+	if cfg.Interface != "" {
+		ip := net.ParseIP(cfg.Interface)
+		if ip == nil {
+			log.Fatalf("Invalid interface address '%s'. Must be an IP address", cfg.Interface)
+		}
+		if !isLocalIP(ip) {
+			log.Printf("Warning: %s is not assigned to any local interface, binding will likely fail", cfg.Interface)
+		}
+		_, port, err := net.SplitHostPort(cfg.HTTPAddress)
+		if err != nil {
+			log.Fatalf("Invalid HTTP address '%s': %v", cfg.HTTPAddress, err)
+		}
+		cfg.HTTPAddress = net.JoinHostPort(cfg.Interface, port)
+	}
+
+	// Report an address clients can reach, not loopback
+	advertiseIP := cfg.AdvertiseIP
+	if advertiseIP != "" {
+		if net.ParseIP(advertiseIP) == nil {
+			log.Fatalf("Invalid advertise IP '%s'. Must be an IP address", advertiseIP)
+		}
+	} else if ip := net.ParseIP(cfg.Interface); ip != nil && !ip.IsUnspecified() {
+		advertiseIP = cfg.Interface
+	} else {
+		advertiseIP = outboundIP()
+	}
+
 	// Calculate total LEDs
-	totalLEDs := cfg.Rows * cfg.Cols
+	totalLEDs := cfg.Rows * cfg.Cols * cfg.Panels
+
+	if *printMAC {
+		fmt.Println(api.MACAddress(cfg.HTTPAddress, cfg.DDPPort, totalLEDs))
+		return
+	}
 
 	// Initialize shared state
-	ledState := state.NewLEDState(totalLEDs, cfg.InitColor)
+	ledState := state.NewLEDState(totalLEDs, cfg.InitColor, state.WithActivityBuffer(cfg.ActivityBuf))
+	ledState.SetRGBW(cfg.RGBW)
+	ledState.SetChannelCap(cfg.ChannelCap)
+	ledState.SetDither(cfg.Dither)
+	ledState.SetLiveDebounce(cfg.LiveDebounce)
+	if cfg.Calibration != "" {
+		cal, err := state.LoadCalibration(cfg.Calibration)
+		if err != nil {
+			log.Fatal(err)
+		}
+		ledState.SetCalibration(cal)
+	}
+	if cfg.InitImage != "" {
+		img, err := imageframe.Load(cfg.InitImage)
+		if err != nil {
+			log.Printf("Warning: %v; starting with %s instead", err, cfg.InitColor)
+		} else {
+			ledState.SetLEDs(imageframe.Tile(imageframe.Frame(img, cfg.Rows, cfg.Cols, cfg.Wiring), cfg.Panels))
+		}
+	}
+
+	// Background watchers stop when main returns
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if cfg.BlackoutIdle {
+		go ledState.BlackoutOnIdle(ctx, 100*time.Millisecond, cfg.BlackoutWipe)
+	}
+	if cfg.Demo {
+		go ledState.RunDemo(ctx, 250*time.Millisecond)
+	}
+	if cfg.PlayImage != "" {
+		anim, err := imageframe.LoadAnimation(cfg.PlayImage, cfg.PlayFPS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		go anim.Play(ctx, ledState, cfg.Rows, cfg.Cols, cfg.Panels, cfg.Wiring)
+	}
+	if cfg.AsciiPreview > 0 {
+		go ascii.Run(ctx, os.Stdout, ledState, cfg.Rows, cfg.Cols, cfg.Wiring, cfg.AsciiPreview, isTerminal(os.Stdout))
+	}
 
 	// Setup logging
 	if cfg.Verbose {
@@ -97,8 +357,17 @@ func main() {
 	}
 
 	fmt.Printf("WLED Simulator starting with %dx%d LED matrix (%d total LEDs, %s-major wiring)\n", cfg.Rows, cfg.Cols, totalLEDs, cfg.Wiring)
-	fmt.Printf("HTTP API on %s\n", cfg.HTTPAddress)
-	fmt.Printf("DDP listening on port %d\n", cfg.DDPPort)
+	if !cfg.NoHTTP {
+		fmt.Printf("HTTP API on %s\n", cfg.HTTPAddress)
+	}
+	switch {
+	case cfg.NoDDP:
+		fmt.Println("DDP disabled")
+	case cfg.StdinDDP:
+		fmt.Println("DDP reading from stdin")
+	default:
+		fmt.Printf("DDP listening on port %d\n", cfg.DDPPort)
+	}
 
 	// Channel for server startup errors
 	startupErrors := make(chan error, 2)
@@ -106,35 +375,54 @@ func main() {
 
 	// Start DDP server
 	ddpServer := ddp.NewServer(cfg.DDPPort, ledState)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := ddpServer.Start(); err != nil {
-			if errors.Is(err, syscall.EADDRINUSE) {
-				startupErrors <- fmt.Errorf("DDP port %d is already in use. Please choose a different port or stop the other process", cfg.DDPPort)
-			} else {
-				startupErrors <- fmt.Errorf("DDP server error: %v", err)
-			}
-			return
+	ddpServer.SetHost(cfg.Interface)
+	ddpServer.SetJitterBuffer(cfg.JitterBuffer)
+	ddpServer.SetOverflow(overflow)
+	ddpServer.SetColorOrder(colorOrder)
+	ddpServer.SetEndian(endian)
+	ddpServer.SetHistory(cfg.History)
+	ddpServer.SetStrictAlignment(cfg.StrictAlign)
+	ddpServer.SetChecksum(cfg.DDPChecksum)
+	ddpServer.SetWrap(cfg.Wrap)
+	ddpServer.SetSeparateWhite(cfg.WhiteOverlay)
+	ddpServer.SetLenientVersion(cfg.LenientVer)
+	ddpServer.SetDeviceSegments(deviceSegments)
+	ddpServer.SetLEDMaps(ledMaps)
+	if err := ddpServer.SetBytesPerPixel(cfg.BPP); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Shm != "" {
+		shmWriter, err := shm.Create(cfg.Shm, totalLEDs)
+		if err != nil {
+			log.Fatal(err)
 		}
-		startupErrors <- nil
-	}()
+		defer shmWriter.Close()
+		ddpServer.SetCommitHandler(func(f ddp.Frame) {
+			if err := shmWriter.Write(f.LEDs); err != nil && cfg.Verbose {
+				log.Printf("Failed to write shared frame: %v", err)
+			}
+		})
+	}
+	startDDP(cfg, ddpServer, startupErrors, &wg)
 
-	// Start HTTP API
-	apiServer := api.NewServer(cfg.HTTPAddress, ledState, cfg.DDPPort)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := apiServer.Start(); err != nil && err != http.ErrServerClosed {
+	// A preview stream decodes like the main one into its own buffer
+	var previewState *state.LEDState
+	if cfg.PreviewPort != 0 && !cfg.NoDDP {
+		previewState = state.NewLEDState(totalLEDs, "#000000")
+		previewState.SetRGBW(cfg.RGBW)
+		previewServer := ddpServer.NewPreview(cfg.PreviewPort, previewState)
+		if err := previewServer.Start(); err != nil {
 			if errors.Is(err, syscall.EADDRINUSE) {
-				startupErrors <- fmt.Errorf("HTTP port %s is already in use. Please choose a different port or stop the other process", cfg.HTTPAddress)
-			} else {
-				startupErrors <- fmt.Errorf("API server error: %v", err)
+				log.Fatalf("DDP preview port %d is already in use. Please choose a different port or stop the other process", cfg.PreviewPort)
 			}
-			return
+			log.Fatalf("Failed to start preview DDP server: %v", err)
 		}
-		startupErrors <- nil
-	}()
+		defer previewServer.Stop()
+		fmt.Printf("DDP preview listening on port %d\n", cfg.PreviewPort)
+	}
+
+	// Start HTTP API
+	apiServer := startAPI(cfg, ledState, ddpServer, advertiseIP, startupErrors, &wg)
 
 	// Wait for both servers to start and check for errors
 	fmt.Println("Starting servers...")
@@ -142,7 +430,9 @@ func main() {
 		if err := <-startupErrors; err != nil {
 			// Stop any successfully started servers
 			ddpServer.Stop()
-			apiServer.Stop()
+			if apiServer != nil {
+				apiServer.Stop()
+			}
 			// Wait for goroutines to finish
 			wg.Wait()
 			log.Fatalf("Failed to start servers: %v", err)
@@ -153,22 +443,68 @@ func main() {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
+	// Shut down like a SIGTERM once DDP traffic has been idle long enough
+	if cfg.IdleExit > 0 {
+		go func() {
+			if ledState.WaitForIdle(ctx, cfg.IdleExit) {
+				fmt.Printf("\nNo DDP packets for %v, exiting...\n", cfg.IdleExit)
+				select {
+				case c <- syscall.SIGTERM:
+				default: // A shutdown is already pending
+				}
+			}
+		}()
+	}
+
+	// Create shutdown function for servers
+	shutdownServers := func() {
+		// Stop servers first
+		if err := ddpServer.Stop(); err != nil {
+			log.Printf("Error stopping DDP server: %v", err)
+		}
+		if apiServer != nil {
+			if err := apiServer.Stop(); err != nil {
+				log.Printf("Error stopping API server: %v", err)
+			}
+		}
+	}
+
 	// Start GUI if not headless
 	if !cfg.Headless {
 		fmt.Println("Starting GUI...")
 		myApp := app.NewWithID("com.example.wled-simulator")
-		guiApp := gui.NewApp(myApp, ledState, cfg.Rows, cfg.Cols, cfg.Wiring, cfg.Name, cfg.Controls)
-
-		// Create shutdown function for servers
-		shutdownServers := func() {
-			// Stop servers first
-			if err := ddpServer.Stop(); err != nil {
-				log.Printf("Error stopping DDP server: %v", err)
+		guiApp := gui.NewWindows(myApp, ledState, panelMatrices(cfg), cfg.Controls)
+		guiApp[0].SetSenders(ddpServer.Senders)
+		if apiServer != nil {
+			guiApp[0].SetPresets(apiServer)
+		}
+		for _, w := range guiApp {
+			w.SetRenderChunk(cfg.RenderChunk)
+			w.SetShowRaw(cfg.ShowRaw)
+			w.SetAgeView(cfg.AgeView)
+			w.SetWhiteOverlay(cfg.WhiteOverlay)
+			if err := w.SetMinWindowSize(minWindow); err != nil {
+				log.Fatal(err)
 			}
-			if err := apiServer.Stop(); err != nil {
-				log.Printf("Error stopping API server: %v", err)
+			if cfg.Layout == "ring" {
+				if err := w.SetRingLayout(float32(cfg.RingRadius)); err != nil {
+					log.Fatal(err)
+				}
 			}
 		}
+		if previewState != nil {
+			previewGUI := gui.NewApp(myApp, previewState, cfg.Rows, cfg.Cols, cfg.Wiring, "Preview", false)
+			previewGUI.SetRenderChunk(cfg.RenderChunk)
+			previewGUI.SetWhiteOverlay(cfg.WhiteOverlay)
+			if err := previewGUI.SetMinWindowSize(minWindow); err != nil {
+				log.Fatal(err)
+			}
+			previewGUI.Show()
+		}
+
+		if cfg.RenderStats > 0 {
+			go guiApp.LogRenderStats(ctx, cfg.RenderStats)
+		}
 
 		// Set window close handler - this runs on the main UI thread
 		guiApp.SetOnClose(func() {
@@ -196,15 +532,63 @@ func main() {
 		<-c
 		fmt.Println("\nReceived shutdown signal...")
 
-		// Stop servers
-		if err := ddpServer.Stop(); err != nil {
-			log.Printf("Error stopping DDP server: %v", err)
-		}
-		if err := apiServer.Stop(); err != nil {
-			log.Printf("Error stopping API server: %v", err)
+		// A stuck server must not keep the process alive after SIGTERM
+		if !shutdownWithin(shutdownTimeout, shutdownServers, &wg) {
+			log.Printf("Servers did not stop within %v, exiting anyway", shutdownTimeout)
+			os.Exit(1)
 		}
 	}
 
 	fmt.Println("Shutting down...")
 	wg.Wait()
 }
+
+// outboundIP returns the local address used to reach other hosts, or "" if
+// there is no route. Dialing UDP selects a route without sending anything.
+func outboundIP() string {
+	conn, err := net.Dial("udp", "192.0.2.1:9")
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && !addr.IP.IsUnspecified() {
+		return addr.IP.String()
+	}
+	return ""
+}
+
+// isLocalIP reports whether ip is assigned to one of this host's interfaces
+func isLocalIP(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// panelMatrices lays out one window per panel over consecutive LED ranges.
+// Panels are numbered after the display name when there is more than one.
+func panelMatrices(cfg Config) []gui.Matrix {
+	size := cfg.Rows * cfg.Cols
+	matrices := make([]gui.Matrix, cfg.Panels)
+	for i := range matrices {
+		name := cfg.Name
+		if cfg.Panels > 1 {
+			name = strings.TrimSpace(fmt.Sprintf("%s Panel %d", cfg.Name, i+1))
+		}
+		matrices[i] = gui.Matrix{Name: name, Rows: cfg.Rows, Cols: cfg.Cols, Wiring: cfg.Wiring, Start: i * size}
+	}
+	return matrices
+}
+
+// isTerminal reports whether f is a character device, so colour escapes
+// aren't written into redirected output
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
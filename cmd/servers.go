@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"wled-simulator/internal/api"
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/state"
+)
+
+// startAPI configures and starts the JSON API in the background, sending its
+// startup result to startupErrors. With cfg.NoHTTP no port is bound, nil is
+// sent straight away so callers wait for the same number of results, and the
+// returned server is nil.
+func startAPI(cfg Config, ledState *state.LEDState, ddpServer *ddp.Server, advertiseIP string, startupErrors chan<- error, wg *sync.WaitGroup) *api.Server {
+	if cfg.NoHTTP {
+		fmt.Println("HTTP API disabled")
+		startupErrors <- nil
+		return nil
+	}
+
+	apiServer := api.NewServer(cfg.HTTPAddress, ledState, cfg.DDPPort, api.Geometry{
+		Rows:   cfg.Rows,
+		Cols:   cfg.Cols,
+		Wiring: cfg.Wiring,
+	})
+	apiServer.SetFirmware(cfg.FWVersion, cfg.FWVid)
+	apiServer.SetResetReason(cfg.ResetReason)
+	apiServer.SetAdvertiseIP(advertiseIP)
+	if cfg.LeadTo != "" {
+		apiServer.SetLeadTo(strings.Split(cfg.LeadTo, ","))
+	}
+	apiServer.SetDDPServer(ddpServer)
+	apiServer.SetPowerModel(cfg.ChannelMA, cfg.BaseMA)
+	apiServer.SetLatency(cfg.HTTPLatency)
+	if err := apiServer.SetAccessLog(cfg.AccessLog); err != nil {
+		log.Fatal(err)
+	}
+	if err := apiServer.SetSceneFile(cfg.StateFile); err != nil {
+		log.Fatal(err)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := apiServer.Start(); err != nil && err != http.ErrServerClosed {
+			if errors.Is(err, syscall.EADDRINUSE) {
+				startupErrors <- fmt.Errorf("HTTP port %s is already in use. Please choose a different port or stop the other process", cfg.HTTPAddress)
+			} else {
+				startupErrors <- fmt.Errorf("API server error: %v", err)
+			}
+			return
+		}
+		startupErrors <- nil
+	}()
+	return apiServer
+}
+
+// startDDP starts receiving DDP packets in the background, from stdin or UDP,
+// sending the startup result to startupErrors. With cfg.NoDDP nothing is
+// started and nil is sent straight away; the server is left idle so the API
+// still has somewhere to read frames and settings from.
+func startDDP(cfg Config, ddpServer *ddp.Server, startupErrors chan<- error, wg *sync.WaitGroup) {
+	switch {
+	case cfg.NoDDP:
+		startupErrors <- nil
+	case cfg.StdinDDP:
+		// Packets are piped in, so there is no socket to bind. The reader is
+		// not waited for on shutdown as stdin may never be closed.
+		startupErrors <- nil
+		go func() {
+			if err := ddpServer.ServeReader(os.Stdin); err != nil {
+				log.Printf("DDP stdin error: %v", err)
+			}
+			fmt.Println("DDP stdin closed")
+		}()
+	default:
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ddpServer.Start(); err != nil {
+				if errors.Is(err, syscall.EADDRINUSE) {
+					startupErrors <- fmt.Errorf("DDP port %d is already in use. Please choose a different port or stop the other process", cfg.DDPPort)
+				} else {
+					startupErrors <- fmt.Errorf("DDP server error: %v", err)
+				}
+				return
+			}
+			startupErrors <- nil
+		}()
+	}
+}
+
+// shutdownTimeout is how long a headless shutdown waits for the servers and
+// their goroutines before giving up
+const shutdownTimeout = 5 * time.Second
+
+// shutdownWithin calls stop and waits for wg, reporting false if that takes
+// longer than timeout. The stop goroutine is left running in that case, as
+// the caller is expected to exit.
+func shutdownWithin(timeout time.Duration, stop func(), wg *sync.WaitGroup) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		stop()
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
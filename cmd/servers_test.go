@@ -0,0 +1,105 @@
+package main
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/state"
+)
+
+func TestStartAPINoHTTP(t *testing.T) {
+	// Find a free port, then release it for startAPI to (not) bind
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	cfg := Config{NoHTTP: true, HTTPAddress: addr, DDPPort: 4048, Rows: 1, Cols: 1, Wiring: "row", AccessLog: "text"}
+	ledState := state.NewLEDState(1, "#000000")
+	startupErrors := make(chan error, 1)
+	var wg sync.WaitGroup
+
+	srv := startAPI(cfg, ledState, ddp.NewServer(cfg.DDPPort, ledState), "127.0.0.1", startupErrors, &wg)
+	if srv != nil {
+		t.Error("expected no API server with NoHTTP")
+	}
+	select {
+	case err := <-startupErrors:
+		if err != nil {
+			t.Errorf("startup error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a startup result so callers don't block")
+	}
+	wg.Wait()
+
+	if conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond); err == nil {
+		conn.Close()
+		t.Errorf("something is listening on %s with NoHTTP", addr)
+	}
+}
+
+func TestStartDDPNoDDP(t *testing.T) {
+	// Find a free UDP port, then release it for startDDP to (not) bind
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := conn.LocalAddr().(*net.UDPAddr).Port
+	conn.Close()
+
+	cfg := Config{NoDDP: true, DDPPort: port}
+	ledState := state.NewLEDState(1, "#000000")
+	ddpServer := ddp.NewServer(port, ledState)
+	defer ddpServer.Stop()
+	startupErrors := make(chan error, 1)
+	var wg sync.WaitGroup
+
+	startDDP(cfg, ddpServer, startupErrors, &wg)
+	select {
+	case err := <-startupErrors:
+		if err != nil {
+			t.Errorf("startup error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a startup result so callers don't block")
+	}
+
+	// The port is still free for anyone else
+	conn, err = net.ListenUDP("udp", &net.UDPAddr{Port: port})
+	if err != nil {
+		t.Fatalf("UDP port %d was bound with NoDDP: %v", port, err)
+	}
+	conn.Close()
+	wg.Wait()
+}
+
+func TestShutdownWithin(t *testing.T) {
+	var wg sync.WaitGroup
+	if !shutdownWithin(time.Second, func() {}, &wg) {
+		t.Error("expected an immediate stop to finish in time")
+	}
+
+	// A stopper that never returns, like a server stuck on a connection
+	stuck := make(chan struct{})
+	defer close(stuck)
+	start := time.Now()
+	if shutdownWithin(50*time.Millisecond, func() { <-stuck }, &wg) {
+		t.Error("expected a stuck stop to time out")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("shutdownWithin took %v, want about the 50ms timeout", took)
+	}
+
+	// Goroutines still running count as not stopped
+	wg.Add(1)
+	defer wg.Done()
+	if shutdownWithin(50*time.Millisecond, func() {}, &wg) {
+		t.Error("expected a pending goroutine to time out")
+	}
+}
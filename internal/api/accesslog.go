@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessLogEntry is one line of the JSON access log
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	DurationMS float64 `json:"duration_ms"`
+	Remote     string  `json:"remote"`
+}
+
+// jsonLogger returns middleware that writes one JSON object per request to out
+func jsonLogger(out io.Writer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path += "?" + raw
+		}
+
+		c.Next()
+
+		entry := accessLogEntry{
+			Time:       start.Format(time.RFC3339),
+			Method:     c.Request.Method,
+			Path:       path,
+			Status:     c.Writer.Status(),
+			DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			Remote:     c.ClientIP(),
+		}
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return
+		}
+		_, _ = out.Write(append(line, '\n'))
+	}
+}
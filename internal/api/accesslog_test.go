@@ -0,0 +1,57 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(jsonLogger(&buf))
+	r.GET("/json/state", func(c *gin.Context) {
+		c.Status(http.StatusTeapot)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/json/state?v=1", nil)
+	req.RemoteAddr = "192.0.2.10:5555"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("log line is not valid JSON: %v (%q)", err, buf.String())
+	}
+
+	for _, field := range []string{"time", "method", "path", "status", "duration_ms", "remote"} {
+		if _, ok := entry[field]; !ok {
+			t.Errorf("missing field %q in %v", field, entry)
+		}
+	}
+	if entry["method"] != "GET" {
+		t.Errorf("method = %v, want GET", entry["method"])
+	}
+	if entry["path"] != "/json/state?v=1" {
+		t.Errorf("path = %v, want /json/state?v=1", entry["path"])
+	}
+	if entry["status"] != float64(http.StatusTeapot) {
+		t.Errorf("status = %v, want %d", entry["status"], http.StatusTeapot)
+	}
+	if entry["remote"] != "192.0.2.10" {
+		t.Errorf("remote = %v, want 192.0.2.10", entry["remote"])
+	}
+}
+
+func TestSetAccessLogRejectsUnknownFormat(t *testing.T) {
+	srv := &Server{}
+	if err := srv.SetAccessLog("xml"); err == nil {
+		t.Error("expected error for unknown access log format")
+	}
+	if err := srv.SetAccessLog("json"); err != nil || !srv.jsonLog {
+		t.Errorf("expected json format to be accepted, err=%v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// handleGetCSV streams the stored colours as CSV, one row per LED with its
+// index, matrix position and channels, plus white for RGBW LEDs
+func (s *Server) handleGetCSV(c *gin.Context) {
+	leds := s.state.LEDs()
+	var white []uint8
+	if s.state.RGBW() {
+		white = s.state.White()
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `inline; filename="leds.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	header := []string{"index", "row", "col", "r", "g", "b"}
+	if white != nil {
+		header = append(header, "w")
+	}
+	w.Write(header)
+
+	record := make([]string, len(header))
+	for i, led := range leds {
+		row, col := s.geometry.Position(i)
+		record[0] = strconv.Itoa(i)
+		record[1] = strconv.Itoa(row)
+		record[2] = strconv.Itoa(col)
+		record[3] = strconv.Itoa(int(led.R))
+		record[4] = strconv.Itoa(int(led.G))
+		record[5] = strconv.Itoa(int(led.B))
+		if white != nil {
+			record[6] = strconv.Itoa(int(white[i]))
+		}
+		if err := w.Write(record); err != nil {
+			return // Client went away
+		}
+	}
+	w.Flush()
+}
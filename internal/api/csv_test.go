@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/csv"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestGetCSV(t *testing.T) {
+	geometry := Geometry{Rows: 2, Cols: 3, Wiring: "col"}
+	ledState := state.NewLEDState(geometry.Count(), "#000000")
+	gradient := make([]color.RGBA, geometry.Count())
+	for i := range gradient {
+		gradient[i] = color.RGBA{R: uint8(i * 50), G: 10, B: uint8(255 - i*50), A: 255}
+	}
+	ledState.SetLEDs(gradient)
+	srv := NewServer(":0", ledState, testDDPPort, geometry)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/csv", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+
+	rows, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("bad CSV: %v", err)
+	}
+	if len(rows) != 1+geometry.Count() {
+		t.Fatalf("got %d rows, want header and %d LEDs", len(rows), geometry.Count())
+	}
+	if got := strings.Join(rows[0], ","); got != "index,row,col,r,g,b" {
+		t.Errorf("header = %q", got)
+	}
+	// Column wiring puts LED 3 in the second row of the second column
+	if got := strings.Join(rows[4], ","); got != "3,1,1,150,10,105" {
+		t.Errorf("LED 3 = %q, want 3,1,1,150,10,105", got)
+	}
+}
+
+func TestGetCSVRGBW(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	ledState.SetRGBW(true)
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 2, Wiring: "row"})
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/csv", nil))
+	header, _, _ := strings.Cut(w.Body.String(), "\n")
+	if header != "index,row,col,r,g,b,w" {
+		t.Errorf("header = %q, want a w column", header)
+	}
+}
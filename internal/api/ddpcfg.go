@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ddpCfgPayload changes the DDP decoding options; omitted fields keep their
+// current values
+type ddpCfgPayload struct {
+	BytesPerPixel *int    `json:"bpp,omitempty"`
+	ColorOrder    *string `json:"order,omitempty"`
+	SeqCheck      *bool   `json:"seqcheck,omitempty"`
+}
+
+func (s *Server) handleGetDDPConfig(c *gin.Context) {
+	if s.ddp == nil {
+		respond(c, http.StatusNotFound, gin.H{"error": "DDP not available"})
+		return
+	}
+	respond(c, http.StatusOK, s.ddp.DecodeConfig())
+}
+
+// handlePostDDPConfig updates the DDP decoding options and responds with the
+// resulting config. Invalid values leave every option unchanged.
+func (s *Server) handlePostDDPConfig(c *gin.Context) {
+	if s.ddp == nil {
+		respond(c, http.StatusNotFound, gin.H{"error": "DDP not available"})
+		return
+	}
+	var p ddpCfgPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cfg := s.ddp.DecodeConfig()
+	if p.BytesPerPixel != nil {
+		cfg.BytesPerPixel = *p.BytesPerPixel
+	}
+	if p.ColorOrder != nil {
+		cfg.ColorOrder = *p.ColorOrder
+	}
+	if p.SeqCheck != nil {
+		cfg.SeqCheck = *p.SeqCheck
+	}
+	if err := s.ddp.SetDecodeConfig(cfg); err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	respond(c, http.StatusOK, s.ddp.DecodeConfig())
+}
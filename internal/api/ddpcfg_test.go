@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/ddp/ddptest"
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDDPConfigColorOrder(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	ddpServer := ddp.NewServer(testDDPPort, ledState)
+	srv.SetDDPServer(ddpServer)
+
+	r := gin.Default()
+	r.GET("/json/ddpcfg", srv.handleGetDDPConfig)
+	r.POST("/json/ddpcfg", srv.handlePostDDPConfig)
+	post := func(body string) (int, ddp.DecodeConfig) {
+		req := httptest.NewRequest(http.MethodPost, "/json/ddpcfg", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var cfg ddp.DecodeConfig
+		json.Unmarshal(w.Body.Bytes(), &cfg)
+		return w.Code, cfg
+	}
+
+	code, cfg := post(`{"order":"grb"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if cfg != (ddp.DecodeConfig{BytesPerPixel: 3, ColorOrder: "GRB", SeqCheck: true}) {
+		t.Errorf("config = %+v, want GRB with other options unchanged", cfg)
+	}
+
+	// Bytes sent as 255,0,0 are now green first
+	frame := ddptest.RGBFrame(1, 0, []color.RGBA{{255, 0, 0, 255}})
+	if err := ddpServer.ServeReader(bytes.NewReader(ddptest.Stream(frame))); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("LED 0 = %v, want green after switching to GRB", got)
+	}
+
+	// Invalid values are rejected without applying the rest
+	if code, _ := post(`{"bpp":4,"order":"RGX"}`); code != http.StatusBadRequest {
+		t.Errorf("invalid order returned %d, want 400", code)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/ddpcfg", nil))
+	json.Unmarshal(w.Body.Bytes(), &cfg)
+	if cfg.BytesPerPixel != 3 || cfg.ColorOrder != "GRB" {
+		t.Errorf("config after rejected update = %+v, want unchanged", cfg)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// eventBuffer is how many activity events may wait for a slow stream
+	// client before new ones are dropped
+	eventBuffer = 64
+	// statePollInterval is how often event streams check for state changes
+	statePollInterval = 250 * time.Millisecond
+)
+
+// activityEvent is the data of an SSE activity event
+type activityEvent struct {
+	Type    string    `json:"type"` // "json" or "ddp"
+	Success bool      `json:"success"`
+	Time    time.Time `json:"time"`
+}
+
+// handleEvents streams Server-Sent Events until the client disconnects or
+// the server stops: an "activity" event for each JSON or DDP request, and a
+// "state" event with the state object on connect and whenever it changes.
+func (s *Server) handleEvents(c *gin.Context) {
+	events, unsubscribe := s.state.SubscribeActivity(eventBuffer)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	var last []byte
+	sendState := func() {
+		data, err := json.Marshal(s.stateObject())
+		if err != nil || string(data) == string(last) {
+			return
+		}
+		last = data
+		writeEvent(c.Writer, "state", data)
+		c.Writer.Flush()
+	}
+	sendState()
+
+	ticker := time.NewTicker(statePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-s.streamsDone:
+			return
+		case ev := <-events:
+			data, _ := json.Marshal(activityEvent{Type: ev.Type.String(), Success: ev.Success, Time: ev.Timestamp})
+			writeEvent(c.Writer, "activity", data)
+			c.Writer.Flush()
+		case <-ticker.C:
+			sendState()
+		}
+	}
+}
+
+// writeEvent writes one SSE event with a single line of JSON data
+func writeEvent(w io.Writer, name string, data []byte) {
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, data)
+}
+
+// endStreams closes every open event stream, which would otherwise keep
+// Shutdown waiting forever
+func (s *Server) endStreams() {
+	s.streamsOnce.Do(func() { close(s.streamsDone) })
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/state"
+)
+
+// readEvent reads the next SSE event from r, returning its name and data
+func readEvent(t *testing.T, r *bufio.Reader) (name, data string) {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading event stream: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if name != "" {
+				return name, data
+			}
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestEventsStreamsDDPActivity(t *testing.T) {
+	const ddpPort = 4067
+	ledState := state.NewLEDState(2, "#000000")
+	ddpServer := ddp.NewServer(ddpPort, ledState)
+	if err := ddpServer.Start(); err != nil {
+		t.Fatalf("DDP server failed to start: %v", err)
+	}
+	defer ddpServer.Stop()
+
+	srv := NewServer(":0", ledState, ddpPort, Geometry{Rows: 1, Cols: 2, Wiring: "row"})
+	hs := httptest.NewServer(srv.Handler())
+	defer hs.Close()
+	defer srv.endStreams()
+
+	resp, err := http.Get(hs.URL + "/json/events")
+	if err != nil {
+		t.Fatalf("GET /json/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	// The current state comes first
+	if name, data := readEvent(t, events); name != "state" || !strings.Contains(data, `"live":false`) {
+		t.Fatalf("first event = %s %s, want the state", name, data)
+	}
+
+	// Version 1 + push, sequence 1, RGB 8-bit, device 1, offset 0, length 3
+	conn, err := net.Dial("udp", "127.0.0.1:4067")
+	if err != nil {
+		t.Fatalf("failed to dial DDP server: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte{0x41, 0x01, 0x0B, 0x01, 0, 0, 0, 0, 0, 3, 255, 0, 0}); err != nil {
+		t.Fatalf("failed to send DDP packet: %v", err)
+	}
+
+	// The packet is reported, and the state follows as the device goes live
+	var activity activityEvent
+	sawLive := false
+	deadline := time.Now().Add(2 * time.Second)
+	for (activity.Type == "" || !sawLive) && time.Now().Before(deadline) {
+		name, data := readEvent(t, events)
+		switch name {
+		case "activity":
+			if err := json.Unmarshal([]byte(data), &activity); err != nil {
+				t.Fatalf("invalid activity data %q: %v", data, err)
+			}
+		case "state":
+			sawLive = strings.Contains(data, `"live":true`)
+		}
+	}
+	if activity.Type != "ddp" || !activity.Success {
+		t.Errorf("activity = %+v, want a successful ddp event", activity)
+	}
+	if !sawLive {
+		t.Error("no state event reported the device going live")
+	}
+}
+
+func TestEventsEndOnShutdown(t *testing.T) {
+	srv := NewServer(":0", state.NewLEDState(1, "#000000"), testDDPPort, testGeometry)
+	hs := httptest.NewServer(srv.Handler())
+	defer hs.Close()
+
+	resp, err := http.Get(hs.URL + "/json/events")
+	if err != nil {
+		t.Fatalf("GET /json/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	readEvent(t, events)
+
+	srv.endStreams()
+	done := make(chan error, 1)
+	go func() {
+		_, err := events.ReadString('\n')
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("stream still open after the server stopped")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream did not end when the server stopped")
+	}
+}
+
+func TestEventsStreamAfterRestart(t *testing.T) {
+	const addr = ":8086"
+	srv := NewServer(addr, state.NewLEDState(1, "#000000"), testDDPPort, testGeometry)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	defer srv.Stop()
+
+	resp, err := http.Get("http://localhost" + addr + "/json/events")
+	if err != nil {
+		t.Fatalf("GET /json/events failed: %v", err)
+	}
+	defer resp.Body.Close()
+	events := bufio.NewReader(resp.Body)
+	readEvent(t, events)
+
+	// Nothing changes, so an open stream has nothing more to send
+	done := make(chan error, 1)
+	go func() {
+		_, err := events.ReadString('\n')
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		t.Errorf("stream ended right after the restart: %v", err)
+	case <-time.After(2 * statePollInterval):
+	}
+}
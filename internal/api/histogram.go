@@ -0,0 +1,69 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// histogramBuckets is how many equal ranges of brightness pixels are counted in
+const histogramBuckets = 8
+
+// channelStats summarises one colour channel over a frame
+type channelStats struct {
+	Min int     `json:"min"`
+	Max int     `json:"max"`
+	Avg float64 `json:"avg"`
+}
+
+// colorHistogram summarises a frame's colours: per-channel statistics and
+// pixel counts by brightness, the largest of a pixel's three channels
+type colorHistogram struct {
+	Count      int          `json:"count"`
+	R          channelStats `json:"r"`
+	G          channelStats `json:"g"`
+	B          channelStats `json:"b"`
+	Brightness []int        `json:"bri"`
+	Black      int          `json:"black"` // Pixels with every channel 0
+}
+
+// handleGetHistogram summarises the stored LED colours, before brightness
+// and segment settings, to check what an effect actually outputs
+func (s *Server) handleGetHistogram(c *gin.Context) {
+	respond(c, http.StatusOK, histogram(s.state.LEDs()))
+}
+
+// histogram computes the colour summary of leds in a single pass
+func histogram(leds []color.RGBA) colorHistogram {
+	h := colorHistogram{Count: len(leds), Brightness: make([]int, histogramBuckets)}
+	if len(leds) == 0 {
+		return h
+	}
+
+	var sum [3]int
+	lo := [3]uint8{255, 255, 255}
+	var hi [3]uint8
+	for _, led := range leds {
+		for ch, v := range [3]uint8{led.R, led.G, led.B} {
+			sum[ch] += int(v)
+			lo[ch] = min(lo[ch], v)
+			hi[ch] = max(hi[ch], v)
+		}
+		bri := max(led.R, led.G, led.B)
+		if bri == 0 {
+			h.Black++
+		}
+		h.Brightness[int(bri)*histogramBuckets/256]++
+	}
+
+	stats := func(ch int) channelStats {
+		return channelStats{
+			Min: int(lo[ch]),
+			Max: int(hi[ch]),
+			Avg: float64(sum[ch]) / float64(len(leds)),
+		}
+	}
+	h.R, h.G, h.B = stats(0), stats(1), stats(2)
+	return h
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestGetHistogram(t *testing.T) {
+	ledState := state.NewLEDState(4, "#000000")
+	ledState.SetLEDs([]color.RGBA{
+		{R: 255, G: 0, B: 0, A: 255},
+		{R: 0, G: 100, B: 0, A: 255},
+		{R: 0, G: 0, B: 0, A: 255},
+		{R: 29, G: 20, B: 8, A: 255},
+	})
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/histogram", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+
+	var h colorHistogram
+	if err := json.Unmarshal(w.Body.Bytes(), &h); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if h.Count != 4 || h.Black != 1 {
+		t.Errorf("count = %d, black = %d, want 4 and 1", h.Count, h.Black)
+	}
+	for name, tt := range map[string]struct {
+		got  channelStats
+		want channelStats
+	}{
+		"r": {h.R, channelStats{Min: 0, Max: 255, Avg: 71}},
+		"g": {h.G, channelStats{Min: 0, Max: 100, Avg: 30}},
+		"b": {h.B, channelStats{Min: 0, Max: 8, Avg: 2}},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %+v, want %+v", name, tt.got, tt.want)
+		}
+	}
+	// Brightness 0 and 29 fall in the first bucket, 100 in the fourth and 255 in the last
+	if want := []int{2, 0, 0, 1, 0, 0, 0, 1}; !equalInts(h.Brightness, want) {
+		t.Errorf("bri = %v, want %v", h.Brightness, want)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// healthPath answers liveness probes, which are never delayed
+const healthPath = "/health"
+
+// SetLatency delays every response except health checks by d, to test how
+// clients cope with a slow device. Zero disables the delay. Must be called
+// before Start.
+func (s *Server) SetLatency(d time.Duration) {
+	s.latency = max(d, 0)
+}
+
+// delay is middleware that waits out the configured latency before handling
+// the request, giving up early if the client disconnects
+func (s *Server) delay(c *gin.Context) {
+	if c.Request.URL.Path == healthPath {
+		return
+	}
+	timer := time.NewTimer(s.latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+		c.Abort()
+	}
+}
+
+// handleHealth reports that the server is up
+func (s *Server) handleHealth(c *gin.Context) {
+	respond(c, http.StatusOK, gin.H{"status": "ok"})
+}
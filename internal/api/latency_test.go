@@ -0,0 +1,31 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+func TestLatency(t *testing.T) {
+	const latency = 100 * time.Millisecond
+	srv := NewServer(":0", state.NewLEDState(testLEDs, "#000000"), testDDPPort, testGeometry)
+	srv.SetLatency(latency)
+	handler := srv.Handler()
+
+	get := func(path string) (int, time.Duration) {
+		start := time.Now()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code, time.Since(start)
+	}
+
+	if code, took := get("/json/info"); code != http.StatusOK || took < latency {
+		t.Errorf("GET /json/info = %d after %v, want 200 after at least %v", code, took, latency)
+	}
+	if code, took := get(healthPath); code != http.StatusOK || took >= latency {
+		t.Errorf("GET %s = %d after %v, want 200 without the delay", healthPath, code, took)
+	}
+}
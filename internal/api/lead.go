@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// leadQueueSize is how many state changes may wait to be forwarded before
+// new ones are dropped
+const leadQueueSize = 64
+
+// leader forwards accepted state changes to follower simulators in the order
+// they were applied
+type leader struct {
+	urls   []string
+	client *http.Client
+	queue  chan []byte
+}
+
+func newLeader(urls []string) *leader {
+	l := &leader{
+		urls:   urls,
+		client: &http.Client{Timeout: 2 * time.Second},
+		queue:  make(chan []byte, leadQueueSize),
+	}
+	go l.run()
+	return l
+}
+
+// forward queues a /json/state body without blocking the request
+func (l *leader) forward(body []byte) {
+	select {
+	case l.queue <- body:
+	default:
+		log.Printf("Lead: queue full, dropping state change")
+	}
+}
+
+func (l *leader) run() {
+	for body := range l.queue {
+		for _, url := range l.urls {
+			resp, err := l.client.Post(url+"/json/state", "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("Lead: failed to forward to %s: %v", url, err)
+				continue
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				log.Printf("Lead: %s rejected state change: %s", url, resp.Status)
+			}
+		}
+	}
+}
+
+func (l *leader) stop() {
+	close(l.queue)
+}
+
+// SetLeadTo forwards every accepted POST /json/state to the followers at
+// urls, given as base URLs such as "http://192.168.1.20:8080"
+func (s *Server) SetLeadTo(urls []string) {
+	s.stopLeader()
+	var clean []string
+	for _, u := range urls {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			clean = append(clean, u)
+		}
+	}
+	s.leadTo = clean
+	if len(clean) > 0 {
+		s.leader = newLeader(clean)
+	}
+}
+
+// stopLeader stops forwarding, leaving leadTo so Start can resume it
+func (s *Server) stopLeader() {
+	if s.leader != nil {
+		s.leader.stop()
+		s.leader = nil
+	}
+}
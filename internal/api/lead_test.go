@@ -0,0 +1,75 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLeadToFollower(t *testing.T) {
+	followerState := state.NewLEDState(testLEDs, "#000000")
+	follower := NewServer(":0", followerState, testDDPPort, testGeometry)
+	fr := gin.Default()
+	fr.POST("/json/state", follower.handlePostState)
+	followerHTTP := httptest.NewServer(fr)
+	defer followerHTTP.Close()
+
+	leaderState := state.NewLEDState(testLEDs, "#000000")
+	leader := NewServer(":0", leaderState, testDDPPort, testGeometry)
+	leader.SetLeadTo([]string{followerHTTP.URL + "/"})
+	lr := gin.Default()
+	lr.POST("/json/state", leader.handlePostState)
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"seg":[{"col":[[0,255,0]]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	lr.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+
+	green := color.RGBA{0, 255, 0, 255}
+	if got := leaderState.LEDs()[0]; got != green {
+		t.Fatalf("leader LED 0 = %v, want %v", got, green)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for followerState.LEDs()[0] != green {
+		if time.Now().After(deadline) {
+			t.Fatalf("follower LED 0 = %v, want %v", followerState.LEDs()[0], green)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLeadToSurvivesRestart(t *testing.T) {
+	srv := NewServer(":8085", state.NewLEDState(testLEDs, "#000000"), testDDPPort, testGeometry)
+	srv.SetLeadTo([]string{"http://127.0.0.1:1"})
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if srv.leader != nil {
+		t.Fatal("leader still running after Stop")
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	defer srv.Stop()
+	if srv.leader == nil {
+		t.Fatal("leader not resumed after restart")
+	}
+	if got := srv.leader.urls; len(got) != 1 || got[0] != "http://127.0.0.1:1" {
+		t.Errorf("leader urls = %v, want the configured follower", got)
+	}
+}
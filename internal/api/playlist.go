@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultPlaylistDur is the WLED default time each playlist entry is shown,
+// in 100ms units
+const defaultPlaylistDur = 100
+
+// playlistPayload cycles saved presets. Presets are scenes saved under their
+// number, so ps entry 1 applies the scene named "1".
+type playlistPayload struct {
+	PS  []int `json:"ps"`
+	Dur []int `json:"dur,omitempty"` // Per entry, in 100ms units; the last repeats
+}
+
+// playlist tracks the running playlist goroutine so a new command can stop it
+type playlist struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// stop cancels the running playlist, if any
+func (pl *playlist) stop() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.cancel != nil {
+		pl.cancel()
+		pl.cancel = nil
+	}
+}
+
+// start replaces any running playlist with run
+func (pl *playlist) start(run func(ctx context.Context)) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if pl.cancel != nil {
+		pl.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pl.cancel = cancel
+	go run(ctx)
+}
+
+// durations returns how long each of the n entries is shown
+func (p playlistPayload) durations() []time.Duration {
+	out := make([]time.Duration, len(p.PS))
+	for i := range out {
+		d := defaultPlaylistDur
+		if len(p.Dur) > 0 {
+			d = p.Dur[min(i, len(p.Dur)-1)]
+		}
+		out[i] = time.Duration(d) * transitionUnit
+	}
+	return out
+}
+
+// validatePlaylist checks every preset exists before the playlist starts
+func (s *Server) validatePlaylist(p playlistPayload) error {
+	if len(p.PS) == 0 {
+		return fmt.Errorf("playlist must contain at least one preset")
+	}
+	for _, d := range p.Dur {
+		if d <= 0 {
+			return fmt.Errorf("playlist durations must be positive")
+		}
+	}
+	for _, id := range p.PS {
+		if _, ok := s.scenes.get(strconv.Itoa(id)); !ok {
+			return fmt.Errorf("Preset %d not found", id)
+		}
+	}
+	return nil
+}
+
+// runPlaylist applies each preset in turn for its duration, looping until
+// ctx is cancelled
+func (s *Server) runPlaylist(ctx context.Context, p playlistPayload) {
+	durs := p.durations()
+	for i := 0; ; i = (i + 1) % len(p.PS) {
+		snap, ok := s.scenes.get(strconv.Itoa(p.PS[i]))
+		if ok {
+			if err := s.state.Restore(snap); err != nil {
+				log.Printf("Playlist: preset %d: %v", p.PS[i], err)
+			}
+		}
+
+		timer := time.NewTimer(durs[i])
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
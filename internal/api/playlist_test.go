@@ -0,0 +1,75 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPlaylistAdvances(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	ledState := state.NewLEDState(testLEDs, "#FF0000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	defer srv.Stop()
+
+	// Presets are scenes saved under their number
+	if err := srv.scenes.put("1", ledState.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < testLEDs; i++ {
+		ledState.SetLED(i, blue)
+	}
+	if err := srv.scenes.put("2", ledState.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(`{"playlist":{"ps":[1,2],"dur":[1,1]}}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+
+	waitFor := func(want color.RGBA) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for ledState.LEDs()[0] != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("playlist never showed %v", want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(red)
+	waitFor(blue)
+	waitFor(red) // Loops back to the first preset
+
+	// A new command stops the playlist
+	if code := post(`{"bri":128}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	ledState.SetLED(0, color.RGBA{0, 255, 0, 255})
+	time.Sleep(250 * time.Millisecond)
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("LED 0 = %v after stopping the playlist, want green", got)
+	}
+
+	if code := post(`{"playlist":{"ps":[1,9]}}`); code != http.StatusBadRequest {
+		t.Errorf("unknown preset returned %d, want 400", code)
+	}
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSceneName is the longest scene name accepted after sanitizing
+const maxSceneName = 32
+
+// sceneStore holds named state snapshots, optionally persisted to a JSON file
+type sceneStore struct {
+	mu     sync.Mutex
+	scenes map[string]state.Snapshot
+	path   string // Empty keeps scenes in memory only
+}
+
+func newSceneStore() *sceneStore {
+	return &sceneStore{scenes: make(map[string]state.Snapshot)}
+}
+
+// load replaces the stored scenes with those in path and persists future
+// saves there. A missing file starts an empty store.
+func (st *sceneStore) load(path string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.path = path
+	st.scenes = make(map[string]state.Snapshot)
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %v", err)
+	}
+	if err := json.Unmarshal(data, &st.scenes); err != nil {
+		return fmt.Errorf("failed to parse state file '%s': %v", path, err)
+	}
+	return nil
+}
+
+func (st *sceneStore) get(name string) (state.Snapshot, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	snap, ok := st.scenes[name]
+	return snap, ok
+}
+
+// put stores a scene and writes the whole store to the state file, if any
+func (st *sceneStore) put(name string, snap state.Snapshot) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.scenes[name] = snap
+	if st.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(st.scenes)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(st.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	return nil
+}
+
+// names returns the stored scene names, numbered presets first in numeric
+// order and then the rest alphabetically
+func (st *sceneStore) names() []string {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	names := make([]string, 0, len(st.scenes))
+	for name := range st.scenes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, errA := strconv.Atoi(names[i])
+		b, errB := strconv.Atoi(names[j])
+		switch {
+		case errA == nil && errB == nil:
+			return a < b
+		case errA == nil || errB == nil:
+			return errA == nil
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// sanitizeSceneName lowercases and trims a scene name so lookups are case
+// insensitive. Only letters, digits, spaces, '-' and '_' are allowed.
+func sanitizeSceneName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", fmt.Errorf("scene name must not be empty")
+	}
+	if len(name) > maxSceneName {
+		return "", fmt.Errorf("scene name longer than %d characters", maxSceneName)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == ' ', r == '-', r == '_':
+		default:
+			return "", fmt.Errorf("invalid character %q in scene name", r)
+		}
+	}
+	return name, nil
+}
+
+// SetSceneFile loads named scenes from path and saves new ones back to it.
+// An empty path keeps scenes in memory only.
+func (s *Server) SetSceneFile(path string) error {
+	return s.scenes.load(path)
+}
+
+type scenePayload struct {
+	Name string `json:"name"`
+	Save bool   `json:"save,omitempty"`
+}
+
+// handlePostScene saves the current state under a name when save is set,
+// and applies the named scene otherwise
+func (s *Server) handlePostScene(c *gin.Context) {
+	var p scenePayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := sanitizeSceneName(p.Name)
+	if err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if p.Save {
+		if err := s.scenes.put(name, s.state.Snapshot()); err != nil {
+			respond(c, http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	snap, ok := s.scenes.get(name)
+	if !ok {
+		respond(c, http.StatusNotFound, gin.H{"error": fmt.Sprintf("Scene '%s' not found", name)})
+		return
+	}
+	if err := s.state.Restore(snap); err != nil {
+		respond(c, http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// PresetNames lists the saved scenes in the order the GUI steps through them
+func (s *Server) PresetNames() []string {
+	return s.scenes.names()
+}
+
+// ApplyPreset restores the saved scene called name, taking over from any
+// running playlist like a state change through the API
+func (s *Server) ApplyPreset(name string) error {
+	snap, ok := s.scenes.get(name)
+	if !ok {
+		return fmt.Errorf("Scene '%s' not found", name)
+	}
+	s.playlist.stop()
+	return s.state.Restore(snap)
+}
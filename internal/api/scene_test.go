@@ -0,0 +1,103 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSceneRouter(srv *Server) func(body string) int {
+	r := gin.Default()
+	r.POST("/json/scene", srv.handlePostScene)
+	return func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/scene", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+}
+
+func TestSceneSaveAndRecall(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	ledState := state.NewLEDState(testLEDs, "#FF0000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	post := newSceneRouter(srv)
+
+	if code := post(`{"name":"Sunset","save":true}`); code != http.StatusNoContent {
+		t.Fatalf("save returned %d, want 204", code)
+	}
+
+	// Change the colours, then recall with different case
+	for i := 0; i < testLEDs; i++ {
+		ledState.SetLED(i, color.RGBA{0, 0, 255, 255})
+	}
+	if code := post(`{"name":"  SUNSET "}`); code != http.StatusNoContent {
+		t.Fatalf("recall returned %d, want 204", code)
+	}
+	for i, c := range ledState.LEDs() {
+		if c != red {
+			t.Fatalf("LED %d = %v after recall, want %v", i, c, red)
+		}
+	}
+
+	if code := post(`{"name":"sunrise"}`); code != http.StatusNotFound {
+		t.Errorf("unknown scene returned %d, want 404", code)
+	}
+	if code := post(`{"name":"../etc/passwd","save":true}`); code != http.StatusBadRequest {
+		t.Errorf("unsanitary name returned %d, want 400", code)
+	}
+}
+
+func TestScenePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenes.json")
+
+	ledState := state.NewLEDState(testLEDs, "#00FF00")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	if err := srv.SetSceneFile(path); err != nil {
+		t.Fatalf("SetSceneFile failed: %v", err)
+	}
+	if code := newSceneRouter(srv)(`{"name":"green","save":true}`); code != http.StatusNoContent {
+		t.Fatalf("save returned %d, want 204", code)
+	}
+
+	// A new server loads the saved scene from the file
+	other := state.NewLEDState(testLEDs, "#000000")
+	srv2 := NewServer(":0", other, testDDPPort, testGeometry)
+	if err := srv2.SetSceneFile(path); err != nil {
+		t.Fatalf("SetSceneFile failed: %v", err)
+	}
+	if code := newSceneRouter(srv2)(`{"name":"green"}`); code != http.StatusNoContent {
+		t.Fatalf("recall returned %d, want 204", code)
+	}
+	if got := other.LEDs()[0]; got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("LED 0 = %v after recall from file, want green", got)
+	}
+}
+
+func TestSanitizeSceneName(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"Sunset", "sunset", false},
+		{" Late Night_2 ", "late night_2", false},
+		{"", "", true},
+		{"a/b", "", true},
+		{strings.Repeat("x", maxSceneName+1), "", true},
+	}
+	for _, tt := range tests {
+		got, err := sanitizeSceneName(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("sanitizeSceneName(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
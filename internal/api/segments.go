@@ -0,0 +1,60 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+
+	"wled-simulator/internal/state"
+
+	"github.com/gin-gonic/gin"
+)
+
+// segmentsPayload is a segment layout as GET /json/segments returns it
+type segmentsPayload struct {
+	MainSeg int          `json:"mainseg"`
+	Seg     []segPayload `json:"seg"`
+}
+
+// handleGetSegments returns the segment layout on its own, with the seg
+// objects of /json/state, in the form POST /json/segments accepts
+func (s *Server) handleGetSegments(c *gin.Context) {
+	layout := s.state.SaveSegments()
+	respond(c, http.StatusOK, gin.H{
+		"mainseg": layout.MainSeg,
+		"seg":     segmentObjects(layout.Segments),
+	})
+}
+
+// handlePostSegments replaces every segment with a layout from GET
+// /json/segments, leaving the LED colours alone. Fields a seg object leaves
+// out take the defaults of a new segment, and one without an id takes its
+// position in the array.
+func (s *Server) handlePostSegments(c *gin.Context) {
+	var p segmentsPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	layout := state.SegmentLayout{MainSeg: p.MainSeg, Segments: make([]state.Segment, len(p.Seg))}
+	for i, sp := range p.Seg {
+		seg := state.Segment{ID: i, On: true, Col: color.RGBA{A: 255}}
+		if sp.ID != nil {
+			seg.ID = *sp.ID
+		}
+		if err := sp.applyTo(&seg); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(sp.Col) > 0 {
+			if col, ok := parseColor(sp.Col[0]); ok {
+				seg.Col = col
+			}
+		}
+		layout.Segments[i] = seg
+	}
+	if err := s.state.RestoreSegments(layout); err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestSegmentsSaveRestore(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	handler := srv.Handler()
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	get := func() string {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/segments", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET status = %d, body %s", w.Code, w.Body)
+		}
+		return w.Body.String()
+	}
+
+	if w := post("/json/state", `{"seg":[{"start":0,"stop":8,"rev":true,"bri":100},{"start":8,"stop":20,"on":false}]}`); w.Code != http.StatusNoContent {
+		t.Fatalf("defining segments: status %d, body %s", w.Code, w.Body)
+	}
+	saved := get()
+
+	// Seg objects match those of /json/state
+	if !strings.Contains(saved, `"col":[[`) || strings.Contains(saved, `"cpal"`) {
+		t.Errorf("saved layout %s, want col as colour arrays and no cpal", saved)
+	}
+
+	// Clear to one segment, with the other fields left to their defaults
+	if w := post("/json/segments", `{"seg":[{"id":0,"start":0,"stop":20}]}`); w.Code != http.StatusNoContent {
+		t.Fatalf("clearing: status %d, body %s", w.Code, w.Body)
+	}
+	segs := ledState.Segments()
+	if len(segs) != 1 {
+		t.Fatalf("cleared to %d segments, want 1", len(segs))
+	}
+	if seg := segs[0]; !seg.On || seg.Brightness() != 255 || seg.ColorTemp() != state.NeutralCCT || seg.Col != (color.RGBA{A: 255}) {
+		t.Errorf("cleared segment %+v, want on at full brightness, neutral and black", seg)
+	}
+
+	if w := post("/json/segments", saved); w.Code != http.StatusNoContent {
+		t.Fatalf("restoring: status %d, body %s", w.Code, w.Body)
+	}
+	if got := get(); got != saved {
+		t.Errorf("restored layout\n%s\nwant\n%s", got, saved)
+	}
+
+	if w := post("/json/segments", `{"seg":[{"id":0,"start":0,"stop":99}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("out of range: status %d, want 400", w.Code)
+	}
+	if w := post("/json/segments", `{"mainseg":5,"seg":[{"id":0,"start":0,"stop":20}]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("missing mainseg: status %d, want 400", w.Code)
+	}
+}
@@ -1,19 +1,61 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"image/color"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"wled-simulator/internal/ddp"
 	"wled-simulator/internal/state"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
 )
 
+// DefaultVersion is the firmware version reported when none is configured
+const DefaultVersion = "simulator"
+
+// DefaultResetReason is the info.resetReason reported when none is configured,
+// as a real device gives after being plugged in
+const DefaultResetReason = "Power on"
+
+// DefaultIP is the address reported when no advertised IP is configured
+const DefaultIP = "127.0.0.1"
+
+// DefaultChannelMA is the typical WS281x draw per colour channel at full intensity
+const DefaultChannelMA = 20
+
+// Geometry describes the physical layout of the LED matrix
+type Geometry struct {
+	Rows   int
+	Cols   int
+	Wiring string // "row" (row-major) or "col" (column-major)
+}
+
+// Count returns the total number of LEDs in the matrix
+func (g Geometry) Count() int {
+	return g.Rows * g.Cols
+}
+
+// Position returns the matrix row and column of LED i, following the wiring.
+// LEDs past the first panel are placed within their own panel.
+func (g Geometry) Position(i int) (row, col int) {
+	if n := g.Count(); n > 0 {
+		i %= n
+	}
+	x, y := state.Grid{Rows: g.Rows, Cols: g.Cols, Wiring: g.Wiring}.Position(i)
+	return y, x
+}
+
 type Server struct {
 	addr     string
 	state    *state.LEDState
@@ -21,19 +63,44 @@ type Server struct {
 	httpPort int
 	ddpPort  int
 	macAddr  string
+	ip       string // Reported as info.ip
+	geometry Geometry
+	version  string // Reported as info.ver
+	vid      int    // Reported as info.vid when non-zero
+	ddp      *ddp.Server
+	jsonLog  bool          // Emit JSON access log lines instead of gin's text format
+	latency  time.Duration // Added before each response, except health checks
+	started  time.Time     // Start time; info.uptime is seconds since
+	reset    string        // Reported as info.resetReason
+	scenes   *sceneStore
+	playlist playlist // Running preset playlist, stopped by the next state change
+	leader   *leader  // Forwards state changes to followers, nil when not leading
+	leadTo   []string // Follower base URLs, kept so Start can lead again after Stop
+	// Closed to end the /json/events streams when the server stops
+	streamsDone chan struct{}
+	streamsOnce sync.Once
+	// Power estimate model for info.leds.pwr
+	channelMA int // Current per colour channel at full intensity
+	baseMA    int // Constant controller draw
 }
 
 // NewServer creates a new API server with the given configuration
-func NewServer(addr string, s *state.LEDState, ddpPort int) *Server {
-	// Extract HTTP port from addr string (format ":8080" or "127.0.0.1:8080")
-	parts := strings.Split(addr, ":")
-	httpPort, _ := strconv.Atoi(parts[len(parts)-1])
-
+func NewServer(addr string, s *state.LEDState, ddpPort int, geometry Geometry) *Server {
 	srv := &Server{
 		addr:     addr,
 		state:    s,
-		httpPort: httpPort,
+		httpPort: parseHTTPPort(addr),
 		ddpPort:  ddpPort,
+		geometry: geometry,
+		ip:       DefaultIP,
+		version:  DefaultVersion,
+		started:  time.Now(),
+		reset:    DefaultResetReason,
+		scenes:   newSceneStore(),
+
+		streamsDone: make(chan struct{}),
+
+		channelMA: DefaultChannelMA,
 	}
 
 	// Generate MAC address once during initialization
@@ -47,8 +114,21 @@ func NewServer(addr string, s *state.LEDState, ddpPort int) *Server {
 	return srv
 }
 
-// generateMACAddress creates a deterministic MAC address based on configuration
+// parseHTTPPort extracts the port from an addr string (format ":8080" or "127.0.0.1:8080")
+func parseHTTPPort(addr string) int {
+	parts := strings.Split(addr, ":")
+	httpPort, _ := strconv.Atoi(parts[len(parts)-1])
+	return httpPort
+}
+
+// generateMACAddress returns the MAC for this server's ports and LED count
 func (s *Server) generateMACAddress() string {
+	return MACAddress(s.addr, s.ddpPort, len(s.state.LEDs()))
+}
+
+// MACAddress creates the deterministic MAC address reported in /json/info
+// for the given configuration, so it can be computed without a server
+func MACAddress(httpAddr string, ddpPort, ledCount int) string {
 	// Use configuration values to generate MAC bytes
 	// Format: WL:ED:HP:DP:LL:LL
 	// WL:ED = Fixed prefix for WLED
@@ -57,7 +137,7 @@ func (s *Server) generateMACAddress() string {
 	// LL:LL = Total LED count as 16-bit number
 
 	// Extract port number from HTTP address
-	httpPort := s.httpPort
+	httpPort := parseHTTPPort(httpAddr)
 	if httpPort == 0 {
 		// Default to 80 if port extraction fails
 		httpPort = 80
@@ -65,10 +145,9 @@ func (s *Server) generateMACAddress() string {
 
 	// Get last byte of ports
 	httpLastByte := byte(httpPort & 0xFF)
-	ddpLastByte := byte(s.ddpPort & 0xFF)
+	ddpLastByte := byte(ddpPort & 0xFF)
 
 	// Get total LED count as 16-bit number
-	ledCount := len(s.state.LEDs())
 	ledCountHigh := byte((ledCount >> 8) & 0xFF)
 	ledCountLow := byte(ledCount & 0xFF)
 
@@ -80,8 +159,16 @@ func (s *Server) generateMACAddress() string {
 	)
 }
 
-func (s *Server) Start() error {
-	r := gin.Default()
+// Handler returns the JSON API routes, for serving them without Start such
+// as from an httptest.Server
+func (s *Server) Handler() http.Handler {
+	var r *gin.Engine
+	if s.jsonLog {
+		r = gin.New()
+		r.Use(jsonLogger(os.Stdout), gin.Recovery())
+	} else {
+		r = gin.Default()
+	}
 
 	// Add middleware to report 404s and other errors as failed activity
 	r.Use(func(c *gin.Context) {
@@ -89,12 +176,17 @@ func (s *Server) Start() error {
 		// Check if this was a JSON API request that failed
 		path := c.Request.URL.Path
 		if path == "/json" || path == "/json/state" || path == "/json/info" {
-			if c.Writer.Status() >= 400 {
+			// 405s are answered by NoMethod and not counted like a 404
+			if status := c.Writer.Status(); status >= 400 && status != http.StatusMethodNotAllowed {
 				s.state.ReportActivity(state.ActivityJSON, false) // Report failed JSON activity
 			}
 		}
 	})
 
+	if s.latency > 0 {
+		r.Use(s.delay)
+	}
+
 	// Add 404 handler
 	r.NoRoute(func(c *gin.Context) {
 		// Report failed activity for ANY 404 request to the HTTP server
@@ -102,15 +194,47 @@ func (s *Server) Start() error {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
 	})
 
+	// Known paths with the wrong method get 405 rather than 404
+	r.HandleMethodNotAllowed = true
+	r.NoMethod(func(c *gin.Context) {
+		c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Method not allowed"})
+	})
+
 	// Add routes
+	r.GET(healthPath, s.handleHealth)
 	r.GET("/json", s.handleGetJSON)
 	r.GET("/json/state", s.handleGetState)
 	r.GET("/json/info", s.handleGetInfo)
+	r.GET("/json/geometry", s.handleGetGeometry)
+	r.GET("/json/segments", s.handleGetSegments)
+	r.GET("/json/lastframe", s.handleGetLastFrame)
+	r.GET("/json/history", s.handleGetHistory)
+	r.GET("/json/histogram", s.handleGetHistogram)
+	r.GET("/json/csv", s.handleGetCSV)
+	r.GET("/json/events", s.handleEvents)
+	// Cheap liveness checks; the status and headers match GET without the body
+	r.HEAD("/json", headOnly(s.handleGetJSON))
+	r.HEAD("/json/state", headOnly(s.handleGetState))
+	r.HEAD("/json/info", headOnly(s.handleGetInfo))
 	r.POST("/json/state", s.handlePostState)
+	r.POST("/json/scene", s.handlePostScene)
+	r.POST("/json/segments", s.handlePostSegments)
+	r.GET("/json/ddpcfg", s.handleGetDDPConfig)
+	r.POST("/json/ddpcfg", s.handlePostDDPConfig)
+	return r
+}
 
+// Start builds a fresh router and http.Server on each call, so a stopped
+// server can be started again
+func (s *Server) Start() error {
 	s.server = &http.Server{
 		Addr:    s.addr,
-		Handler: r,
+		Handler: s.Handler(),
+	}
+	s.streamsDone = make(chan struct{})
+	s.streamsOnce = sync.Once{}
+	if s.leader == nil && len(s.leadTo) > 0 {
+		s.leader = newLeader(s.leadTo)
 	}
 
 	// Try to start the server
@@ -132,95 +256,645 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop() error {
+	s.playlist.stop()
+	var err error
 	if s.server != nil {
-		return s.server.Shutdown(context.Background())
+		// Streams are ended here rather than in a shutdown hook, which runs in
+		// the background and could close the channel of a later Start
+		s.endStreams()
+		err = s.server.Shutdown(context.Background())
+		s.server = nil
+	}
+	s.stopLeader()
+	return err
+}
+
+// SetFirmware sets the firmware version and build number reported in info.
+// An empty version keeps the default and a zero vid omits info.vid.
+func (s *Server) SetFirmware(version string, vid int) {
+	if version == "" {
+		version = DefaultVersion
+	}
+	s.version = version
+	s.vid = vid
+}
+
+// SetResetReason sets the info.resetReason reported, to test how monitoring
+// handles crashes and watchdog resets. An empty reason keeps the default.
+func (s *Server) SetResetReason(reason string) {
+	if reason == "" {
+		reason = DefaultResetReason
+	}
+	s.reset = reason
+}
+
+// SetPowerModel configures the info.leds.pwr estimate: channelMA is the current
+// of one colour channel at full intensity and baseMA is a constant added on top
+func (s *Server) SetPowerModel(channelMA, baseMA int) {
+	s.channelMA = channelMA
+	s.baseMA = baseMA
+}
+
+// estimatePower returns the estimated current draw in mA of the rendered LEDs
+func (s *Server) estimatePower() int {
+	total := 0
+	for _, c := range s.state.RenderedLEDs() {
+		total += int(c.R) + int(c.G) + int(c.B)
+	}
+	return s.baseMA + total*s.channelMA/255
+}
+
+// SetAccessLog selects the access log format: "text" (gin's default) or "json"
+func (s *Server) SetAccessLog(format string) error {
+	switch format {
+	case "text":
+		s.jsonLog = false
+	case "json":
+		s.jsonLog = true
+	default:
+		return fmt.Errorf("invalid access log format '%s'. Must be 'text' or 'json'", format)
 	}
 	return nil
 }
 
+// SetDDPServer gives the API access to the DDP server for frame inspection
+func (s *Server) SetDDPServer(d *ddp.Server) {
+	s.ddp = d
+}
+
+// SetAddress changes the listen address used by the next Start.
+// The server must be stopped; the MAC address is regenerated for the new port.
+func (s *Server) SetAddress(addr string) {
+	s.addr = addr
+	s.httpPort = parseHTTPPort(addr)
+	s.macAddr = s.generateMACAddress()
+}
+
+// SetAdvertiseIP sets the address reported as info.ip, which clients use to
+// build further requests. An empty ip keeps DefaultIP.
+func (s *Server) SetAdvertiseIP(ip string) {
+	if ip == "" {
+		ip = DefaultIP
+	}
+	s.ip = ip
+}
+
 type statePayload struct {
-	On  *bool        `json:"on,omitempty"`
-	Bri *int         `json:"bri,omitempty"`
-	Seg []segPayload `json:"seg,omitempty"`
+	On         *bool            `json:"on,omitempty"`
+	Bri        *levelValue      `json:"bri,omitempty"`
+	Transition *int             `json:"transition,omitempty"` // Default transition, in 100ms units
+	TT         *int             `json:"tt,omitempty"`         // Transition for this request only
+	Seg        segList          `json:"seg,omitempty"`
+	Playlist   *playlistPayload `json:"playlist,omitempty"`
+	NL         *nlPayload       `json:"nl,omitempty"`
+	Identify   bool             `json:"identify,omitempty"` // Blink the output to find this instance
+	LOR        *int             `json:"lor,omitempty"`      // Live override: 0 live data wins, 1 until the stream ends, 2 always
+	LEDMap     *int             `json:"ledmap,omitempty"`   // Loaded ledmap to route DDP pixels through
+	MainSeg    *int             `json:"mainseg,omitempty"`  // Segment id a seg object without an id applies to
+}
+
+// nlPayload configures the nightlight; omitted fields keep their values
+type nlPayload struct {
+	On   *bool `json:"on,omitempty"`
+	Dur  *int  `json:"dur,omitempty"`  // Minutes, 1-255
+	Mode *int  `json:"mode,omitempty"` // 0 instant, 1 fade, 2 color fade, 3 sunrise
+	TBri *int  `json:"tbri,omitempty"` // Target brightness
+}
+
+// validate checks the ranges WLED accepts
+func (p nlPayload) validate() error {
+	if p.Dur != nil && (*p.Dur < 1 || *p.Dur > 255) {
+		return fmt.Errorf("invalid nightlight duration %d. Must be 1-255 minutes", *p.Dur)
+	}
+	if p.Mode != nil && (*p.Mode < 0 || *p.Mode > 3) {
+		return fmt.Errorf("invalid nightlight mode %d. Must be 0-3", *p.Mode)
+	}
+	return nil
+}
+
+// levelValue is a WLED level such as bri, given either as an absolute integer
+// or as a "~N" / "~-N" string relative to the current value
+type levelValue struct {
+	value    int
+	relative bool
 }
 
+func (v *levelValue) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		*v = levelValue{value: n}
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("invalid level %s: must be an integer or \"~N\"", data)
+	}
+	rest, ok := strings.CutPrefix(str, "~")
+	if !ok {
+		return fmt.Errorf("invalid level %q: must be an integer or \"~N\"", str)
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil {
+		return fmt.Errorf("invalid level %q: must be an integer or \"~N\"", str)
+	}
+	*v = levelValue{value: n, relative: true}
+	return nil
+}
+
+// resolve returns the level to apply given the current one, clamped to 0-255
+func (v levelValue) resolve(current int) int {
+	n := v.value
+	if v.relative {
+		n += current
+	}
+	return max(0, min(n, 255))
+}
+
+// transitionUnit is the resolution of WLED's transition and tt fields
+const transitionUnit = 100 * time.Millisecond
+
 type segPayload struct {
-	Col [][]int `json:"col,omitempty"`
+	ID    *int    `json:"id,omitempty"`
+	Start *int    `json:"start,omitempty"`
+	Stop  *int    `json:"stop,omitempty"`
+	Len   *int    `json:"len,omitempty"` // Alternative to stop, counted from start
+	On    *bool   `json:"on,omitempty"`
+	Bri   *int    `json:"bri,omitempty"`
+	CCT   *int    `json:"cct,omitempty"`
+	Rev   *bool   `json:"rev,omitempty"`
+	Frz   *bool   `json:"frz,omitempty"`
+	FX    *int    `json:"fx,omitempty"`
+	Pal   *int    `json:"pal,omitempty"`
+	CPal  [][]int `json:"cpal,omitempty"` // Custom palette stops; [] clears it
+	Col   [][]int `json:"col,omitempty"`
+	main  bool    // Sent as a lone object without an id, so aimed at mainseg
+}
+
+// segList is the seg field: an array of segments, or a single segment object
+// that applies to the main segment unless it has an id
+type segList []segPayload
+
+func (l *segList) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var one segPayload
+		if err := json.Unmarshal(trimmed, &one); err != nil {
+			return err
+		}
+		one.main = one.ID == nil
+		*l = segList{one}
+		return nil
+	}
+	var many []segPayload
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// headWriter discards the response body so a GET handler can answer HEAD
+type headWriter struct {
+	gin.ResponseWriter
+}
+
+func (w headWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	return len(data), nil
+}
+
+func (w headWriter) WriteString(s string) (int, error) {
+	w.WriteHeaderNow()
+	return len(s), nil
+}
+
+// headOnly adapts a GET handler for HEAD requests
+func headOnly(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = headWriter{c.Writer}
+		h(c)
+	}
+}
+
+// respond writes obj as MessagePack when the client's Accept header asks for it,
+// and as JSON otherwise
+func respond(c *gin.Context, code int, obj any) {
+	switch c.NegotiateFormat(binding.MIMEJSON, binding.MIMEMSGPACK2, binding.MIMEMSGPACK) {
+	case binding.MIMEMSGPACK, binding.MIMEMSGPACK2:
+		c.Render(code, render.MsgPack{Data: obj})
+	default:
+		c.JSON(code, obj)
+	}
 }
 
 func (s *Server) handleGetJSON(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"state": gin.H{
-			"on":   s.state.Power(),
-			"bri":  s.state.Brightness(),
-			"live": s.state.IsLive(),
-		},
-		"info": gin.H{
-			"ver":  "simulator",
-			"ip":   "127.0.0.1",
-			"name": "WLED Simulator",
-			"live": s.state.IsLive(),
-			"mac":  s.macAddr,
-			"leds": gin.H{
-				"count": len(s.state.LEDs()),
-			},
-		},
+	respond(c, http.StatusOK, gin.H{
+		"state": s.stateObject(),
+		"info":  s.info(),
 	})
 }
 
 func (s *Server) handleGetState(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"on":   s.state.Power(),
-		"bri":  s.state.Brightness(),
-		"live": s.state.IsLive(),
-	})
+	respond(c, http.StatusOK, s.stateObject())
+}
+
+// stateObject builds the state object shared by /json and /json/state
+func (s *Server) stateObject() gin.H {
+	nl := s.state.Nightlight()
+	rem := -1 // WLED reports -1 when the nightlight is not running
+	if nl.On {
+		rem = int(nl.Remaining.Round(time.Second) / time.Second)
+	}
+	return gin.H{
+		"nl": gin.H{
+			"on":   nl.On,
+			"dur":  int(nl.Duration / time.Minute),
+			"mode": int(nl.Mode),
+			"tbri": nl.Target,
+			"rem":  rem,
+		},
+		"on":         s.state.Power(),
+		"bri":        s.state.Brightness(),
+		"transition": int(s.state.Transition() / transitionUnit),
+		"live":       s.state.IsLive(),
+		"lor":        s.state.LiveOverride(),
+		"mainseg":    s.state.MainSegment(),
+		"seg":        segmentObjects(s.state.Segments()),
+	}
+}
+
+// segmentObjects renders segments as the seg array of the state object
+func segmentObjects(segs []state.Segment) []gin.H {
+	objs := make([]gin.H, len(segs))
+	for i, sg := range segs {
+		objs[i] = gin.H{
+			"id":    sg.ID,
+			"start": sg.Start,
+			"stop":  sg.Stop,
+			"len":   sg.Len(),
+			"on":    sg.On,
+			"bri":   sg.Brightness(),
+			"cct":   sg.ColorTemp(),
+			"rev":   sg.Rev,
+			"frz":   sg.Frz,
+			"fx":    sg.FX,
+			"pal":   sg.Pal,
+			// Only the primary colour is tracked; WLED always sends three
+			"col": [][]int{{int(sg.Col.R), int(sg.Col.G), int(sg.Col.B)}, {0, 0, 0}, {0, 0, 0}},
+		}
+		if len(sg.CPal) > 0 {
+			cpal := make([][]int, len(sg.CPal))
+			for j, c := range sg.CPal {
+				cpal[j] = []int{int(c.R), int(c.G), int(c.B)}
+			}
+			objs[i]["cpal"] = cpal
+		}
+	}
+	return objs
 }
 
 func (s *Server) handleGetInfo(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"ver":  "simulator",
-		"ip":   "127.0.0.1",
-		"name": "WLED Simulator",
-		"live": s.state.IsLive(),
-		"mac":  s.macAddr,
+	respond(c, http.StatusOK, s.info())
+}
+
+// info builds the info object shared by /json and /json/info
+func (s *Server) info() gin.H {
+	info := gin.H{
+		"ver":       s.version,
+		"ip":        s.ip,
+		"name":      "WLED Simulator",
+		"live":      s.state.IsLive(),
+		"connected": s.state.IsConnected(), // Sender present, possibly only heartbeats
+		"mac":       s.macAddr,
+		"uptime":    int(time.Since(s.started).Seconds()),
 		"leds": gin.H{
 			"count": len(s.state.LEDs()),
+			"pwr":   s.estimatePower(),
+			"rgbw":  s.state.RGBW(),
+			"wv":    s.state.RGBW(), // Clients offer a white value control
 		},
+	}
+	if s.vid != 0 {
+		info["vid"] = s.vid
+	}
+	info["resetReason"] = s.reset
+	// 2D clients lay out panels from the matrix size; strips have none
+	if s.geometry.Rows > 1 && s.geometry.Cols > 1 {
+		info["leds"].(gin.H)["matrix"] = gin.H{"w": s.geometry.Cols, "h": s.geometry.Rows}
+	}
+	return info
+}
+
+func (s *Server) handleGetGeometry(c *gin.Context) {
+	respond(c, http.StatusOK, gin.H{
+		"rows":   s.geometry.Rows,
+		"cols":   s.geometry.Cols,
+		"wiring": s.geometry.Wiring,
+		"count":  s.geometry.Count(),
 	})
 }
 
+func (s *Server) handleGetLastFrame(c *gin.Context) {
+	if s.ddp == nil {
+		respond(c, http.StatusNotFound, gin.H{"error": "DDP not available"})
+		return
+	}
+	frame, ok := s.ddp.LastFrame()
+	if !ok {
+		respond(c, http.StatusNotFound, gin.H{"error": "No frame received"})
+		return
+	}
+	respond(c, http.StatusOK, frameObject(frame))
+}
+
+// frameObject renders a committed frame with its LEDs as [r,g,b] arrays
+func frameObject(frame ddp.Frame) gin.H {
+	leds := make([][]int, len(frame.LEDs))
+	for i, led := range frame.LEDs {
+		leds[i] = []int{int(led.R), int(led.G), int(led.B)}
+	}
+	return gin.H{
+		"leds":   leds,
+		"source": frame.Source,
+		"seq":    frame.Sequence,
+	}
+}
+
+// handleGetHistory returns the last n committed frames, oldest first, or all
+// stored frames when n is not given
+func (s *Server) handleGetHistory(c *gin.Context) {
+	if s.ddp == nil {
+		respond(c, http.StatusNotFound, gin.H{"error": "DDP not available"})
+		return
+	}
+	n := -1
+	if q := c.Query("n"); q != "" {
+		v, err := strconv.Atoi(q)
+		if err != nil || v < 0 {
+			respond(c, http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid n '%s'. Must be a non-negative integer", q)})
+			return
+		}
+		n = v
+	}
+
+	history := s.ddp.History(n)
+	frames := make([]gin.H, len(history))
+	for i, f := range history {
+		frames[i] = frameObject(f)
+	}
+	respond(c, http.StatusOK, gin.H{"frames": frames})
+}
+
 func (s *Server) handlePostState(c *gin.Context) {
 	var p statePayload
-	if err := c.ShouldBindJSON(&p); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	body, err := c.GetRawData()
+	if err == nil {
+		err = binding.JSON.BindBody(body, &p)
+	}
+	if err != nil {
+		respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if p.Playlist != nil {
+		if err := s.validatePlaylist(*p.Playlist); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if p.NL != nil {
+		if err := p.NL.validate(); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if p.LOR != nil {
+		if err := s.state.SetLiveOverride(*p.LOR); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if p.LEDMap != nil {
+		if s.ddp == nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": "DDP not available"})
+			return
+		}
+		if err := s.ddp.SelectLEDMap(*p.LEDMap); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// Any new command takes over from a running playlist
+	s.playlist.stop()
 
 	if p.On != nil {
 		s.state.SetPower(*p.On)
 	}
+	if p.Transition != nil {
+		s.state.SetTransition(time.Duration(*p.Transition) * transitionUnit)
+	}
 	if p.Bri != nil {
-		s.state.SetBrightness(*p.Bri)
-	}
-
-	// Process segment colors
-	if len(p.Seg) > 0 && len(p.Seg[0].Col) > 0 {
-		// Get the first color from the first segment
-		col := p.Seg[0].Col[0]
-		if len(col) >= 3 {
-			// Convert RGB values to color.RGBA
-			r := uint8(col[0])
-			g := uint8(col[1])
-			b := uint8(col[2])
-			ledColor := color.RGBA{R: r, G: g, B: b, A: 255}
-
-			// Set all LEDs to this color
-			leds := s.state.LEDs()
-			for i := range leds {
-				s.state.SetLED(i, ledColor)
-			}
+		// tt overrides the stored transition for this request only
+		d := s.state.Transition()
+		if p.TT != nil {
+			d = time.Duration(*p.TT) * transitionUnit
 		}
+		s.state.TransitionBrightness(p.Bri.resolve(s.state.Brightness()), d)
+	}
+	if p.NL != nil {
+		nl := s.state.Nightlight()
+		if p.NL.Dur != nil {
+			nl.Duration = time.Duration(*p.NL.Dur) * time.Minute
+		}
+		if p.NL.Mode != nil {
+			nl.Mode = state.NightlightMode(*p.NL.Mode)
+		}
+		if p.NL.TBri != nil {
+			nl.Target = *p.NL.TBri
+		}
+		if p.NL.On != nil {
+			nl.On = *p.NL.On
+		}
+		s.state.SetNightlight(nl)
+	}
+
+	if p.Identify {
+		s.state.Identify(state.IdentifyFlashes, state.IdentifyPeriod)
+	}
+
+	// Segments are addressed by id, or by their position in the seg array
+	for i, sp := range p.Seg {
+		if err := s.applySegment(i, sp); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// After the segments, so mainseg may name one created by this request
+	if p.MainSeg != nil {
+		if err := s.state.SetMainSegment(*p.MainSeg); err != nil {
+			respond(c, http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if p.Playlist != nil {
+		pl := *p.Playlist
+		s.playlist.start(func(ctx context.Context) { s.runPlaylist(ctx, pl) })
+	}
+	if s.leader != nil {
+		s.leader.forward(body)
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// applySegment updates the segment addressed by the payload, creating it when
+// it does not exist and an explicit start and stop are given, then fills it
+// with the first colour if one was sent. Segments are addressed by id when the
+// payload has one, by their position i in the seg array otherwise, and a lone
+// seg object without an id addresses the main segment.
+func (s *Server) applySegment(i int, sp segPayload) error {
+	if sp.main {
+		id := s.state.MainSegment()
+		sp.ID = &id
+	}
+	segs := s.state.Segments()
+	var seg state.Segment
+	found := false
+	if sp.ID != nil {
+		for _, sg := range segs {
+			if sg.ID == *sp.ID {
+				seg, found = sg, true
+				break
+			}
+		}
+	} else if i < len(segs) {
+		seg, found = segs[i], true
+	}
+
+	if !found {
+		id := i
+		if sp.ID != nil {
+			id = *sp.ID
+		}
+		if sp.Start == nil || (sp.Stop == nil && sp.Len == nil) {
+			return fmt.Errorf("segment %d does not exist and no start/stop or len given", id)
+		}
+		seg = state.Segment{ID: id, On: true, Col: color.RGBA{A: 255}}
+	}
+
+	if err := sp.applyTo(&seg); err != nil {
+		return err
+	}
+	ledColor, fill := color.RGBA{}, false
+	if len(sp.Col) > 0 {
+		ledColor, fill = parseColor(sp.Col[0])
+	}
+	store := func() error {
+		if sp.ID != nil {
+			return s.state.SetSegmentByID(seg)
+		}
+		return s.state.SetSegment(i, seg)
+	}
+	if err := store(); err != nil {
+		return err
+	}
+
+	// Set every LED in the segment to this color. While DDP is live it
+	// takes precedence and the LEDs are left alone, unless lor overrides it;
+	// the segment only reports the colour once it is shown.
+	if fill && s.state.FillRange(seg.Start, seg.Stop, ledColor) {
+		seg.Col = ledColor
+		return store()
+	}
+	return nil
+}
+
+// applyTo sets the fields of seg given in the payload, leaving the rest and
+// the colours alone
+func (sp segPayload) applyTo(seg *state.Segment) error {
+	if sp.Start != nil {
+		seg.Start = *sp.Start
+	}
+	if sp.Stop != nil {
+		seg.Stop = *sp.Stop
+	} else if sp.Len != nil {
+		seg.Stop = seg.Start + *sp.Len
+	}
+	if sp.On != nil {
+		seg.On = *sp.On
+	}
+	if sp.Bri != nil {
+		bri := max(0, min(*sp.Bri, 255))
+		seg.Bri = &bri
+	}
+	if sp.CCT != nil {
+		cct := max(0, min(*sp.CCT, 255))
+		seg.CCT = &cct
+	}
+	if sp.Rev != nil {
+		seg.Rev = *sp.Rev
+	}
+	if sp.Frz != nil {
+		seg.Frz = *sp.Frz
+	}
+	if sp.FX != nil {
+		if !state.ValidEffect(*sp.FX) {
+			return fmt.Errorf("unknown effect %d", *sp.FX)
+		}
+		seg.FX = *sp.FX
+	}
+	if sp.Pal != nil {
+		if !state.ValidPalette(*sp.Pal) {
+			return fmt.Errorf("unknown palette %d (have %d)", *sp.Pal, len(state.Palettes))
+		}
+		seg.Pal = *sp.Pal
+	}
+	if sp.CPal != nil {
+		cpal, err := parseGradient(sp.CPal)
+		if err != nil {
+			return err
+		}
+		seg.CPal = cpal
+	}
+	return nil
+}
+
+// parseGradient converts custom palette stops, each a colour array as for
+// col, to a state.Gradient. No stops gives nil, removing the custom palette.
+func parseGradient(stops [][]int) (state.Gradient, error) {
+	if len(stops) > state.MaxGradientStops {
+		return nil, fmt.Errorf("invalid cpal with %d stops. Must have at most %d", len(stops), state.MaxGradientStops)
+	}
+	if len(stops) == 0 {
+		return nil, nil
+	}
+	g := make(state.Gradient, len(stops))
+	for i, stop := range stops {
+		c, ok := parseColor(stop)
+		if !ok {
+			return nil, fmt.Errorf("invalid cpal stop %d. Must be an [r,g,b] array", i)
+		}
+		g[i] = c
+	}
+	return g, nil
+}
+
+// parseColor converts a WLED colour array to color.RGBA. A single value is
+// grayscale, two values are R and G with B=0, and three or more are RGB.
+func parseColor(col []int) (color.RGBA, bool) {
+	switch len(col) {
+	case 0:
+		return color.RGBA{}, false
+	case 1:
+		v := uint8(col[0])
+		return color.RGBA{R: v, G: v, B: v, A: 255}, true
+	case 2:
+		return color.RGBA{R: uint8(col[0]), G: uint8(col[1]), A: 255}, true
+	default:
+		return color.RGBA{R: uint8(col[0]), G: uint8(col[1]), B: uint8(col[2]), A: 255}, true
+	}
+}
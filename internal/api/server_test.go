@@ -1,17 +1,24 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"image/color"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/ddp/ddptest"
 	"wled-simulator/internal/state"
 
 	"github.com/gin-gonic/gin"
+	"github.com/ugorji/go/codec"
 )
 
 type testState struct {
@@ -21,10 +28,13 @@ type testState struct {
 }
 
 type testInfo struct {
-	Ver  string `json:"ver"`
-	Name string `json:"name"`
-	Live bool   `json:"live"`
-	Mac  string `json:"mac"`
+	Ver       string `json:"ver"`
+	IP        string `json:"ip"`
+	Vid       int    `json:"vid"`
+	Name      string `json:"name"`
+	Live      bool   `json:"live"`
+	Connected bool   `json:"connected"`
+	Mac       string `json:"mac"`
 }
 
 type testCombined struct {
@@ -38,9 +48,11 @@ const (
 	testLEDs    = 20
 )
 
+var testGeometry = Geometry{Rows: 10, Cols: 2, Wiring: "row"}
+
 func TestGetState(t *testing.T) {
 	ledState := state.NewLEDState(testLEDs, "#000000")
-	srv := NewServer(":0", ledState, testDDPPort)
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
 
 	r := gin.Default()
 	r.GET("/json/state", srv.handleGetState)
@@ -68,7 +80,7 @@ func TestGetState(t *testing.T) {
 
 func TestGetInfo(t *testing.T) {
 	ledState := state.NewLEDState(testLEDs, "#000000")
-	srv := NewServer(":0", ledState, testDDPPort)
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
 
 	r := gin.Default()
 	r.GET("/json/info", srv.handleGetInfo)
@@ -98,9 +110,218 @@ func TestGetInfo(t *testing.T) {
 	}
 }
 
+func TestGetInfoFirmwareVersion(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	srv.SetFirmware("0.14.0", 2310130)
+
+	r := gin.Default()
+	r.GET("/json/info", srv.handleGetInfo)
+
+	req := httptest.NewRequest(http.MethodGet, "/json/info", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp testInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if resp.Ver != "0.14.0" {
+		t.Errorf("ver = %q, want %q", resp.Ver, "0.14.0")
+	}
+	if resp.Vid != 2310130 {
+		t.Errorf("vid = %d, want 2310130", resp.Vid)
+	}
+
+	// Clearing the version restores the default and omits vid
+	srv.SetFirmware("", 0)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+	if strings.Contains(w.Body.String(), `"vid"`) {
+		t.Errorf("expected vid to be omitted when unset, got %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"ver":"simulator"`) {
+		t.Errorf("expected default version, got %s", w.Body.String())
+	}
+}
+
+func TestGetInfoAdvertiseIP(t *testing.T) {
+	srv := NewServer(":0", state.NewLEDState(testLEDs, "#000000"), testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.GET("/json", srv.handleGetJSON)
+	get := func() string {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json", nil))
+		var resp testCombined
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		return resp.Info.IP
+	}
+
+	if got := get(); got != DefaultIP {
+		t.Errorf("ip = %q, want default %q", got, DefaultIP)
+	}
+	srv.SetAdvertiseIP("192.168.1.50")
+	if got := get(); got != "192.168.1.50" {
+		t.Errorf("ip = %q, want 192.168.1.50", got)
+	}
+}
+
+func TestGetInfoPowerEstimate(t *testing.T) {
+	ledState := state.NewLEDState(10, "#FFFFFF")
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 10, Wiring: "row"})
+	srv.SetPowerModel(20, 100)
+
+	r := gin.Default()
+	r.GET("/json/info", srv.handleGetInfo)
+
+	var resp struct {
+		Leds struct {
+			Pwr int `json:"pwr"`
+		} `json:"leds"`
+	}
+	get := func() int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		return resp.Leds.Pwr
+	}
+
+	// 10 white LEDs x 3 channels x 20mA + 100mA base
+	if got := get(); got != 700 {
+		t.Errorf("pwr = %d, want 700", got)
+	}
+
+	// Powered off only the base draw remains
+	ledState.SetPower(false)
+	if got := get(); got != 100 {
+		t.Errorf("pwr with power off = %d, want 100", got)
+	}
+}
+
+func TestMACAddressMatchesInfo(t *testing.T) {
+	const addr = "127.0.0.1:9090"
+	ledState := state.NewLEDState(300, "#000000")
+	srv := NewServer(addr, ledState, 4048, testGeometry)
+
+	r := gin.Default()
+	r.GET("/json/info", srv.handleGetInfo)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+
+	var resp testInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	// 9090 = 0x2382, 4048 = 0x0FD0, 300 = 0x012C
+	want := MACAddress(addr, 4048, 300)
+	if want != "WL:ED:82:D0:01:2C" {
+		t.Errorf("MACAddress = %q, want WL:ED:82:D0:01:2C", want)
+	}
+	if resp.Mac != want {
+		t.Errorf("info mac = %q, want %q", resp.Mac, want)
+	}
+}
+
+func TestGetInfoMatrix(t *testing.T) {
+	get := func(geometry Geometry) map[string]any {
+		srv := NewServer(":0", state.NewLEDState(geometry.Count(), "#000000"), testDDPPort, geometry)
+		r := gin.Default()
+		r.GET("/json/info", srv.handleGetInfo)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+
+		var resp struct {
+			Leds map[string]any `json:"leds"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		return resp.Leds
+	}
+
+	leds := get(Geometry{Rows: 16, Cols: 16, Wiring: "row"})
+	matrix, ok := leds["matrix"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected leds.matrix object, got %v", leds["matrix"])
+	}
+	if matrix["w"] != float64(16) || matrix["h"] != float64(16) {
+		t.Errorf("matrix = %v, want w=16 h=16", matrix)
+	}
+
+	if _, ok := get(Geometry{Rows: 1, Cols: 30, Wiring: "row"})["matrix"]; ok {
+		t.Error("expected no leds.matrix for a 1D strip")
+	}
+}
+
+func TestInfoReportsRGBW(t *testing.T) {
+	for _, rgbw := range []bool{false, true} {
+		st := state.NewLEDState(4, "#000000")
+		st.SetRGBW(rgbw)
+		srv := NewServer(":0", st, testDDPPort, Geometry{Rows: 1, Cols: 4, Wiring: "row"})
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+
+		var resp struct {
+			Leds struct {
+				RGBW bool `json:"rgbw"`
+				WV   bool `json:"wv"`
+			} `json:"leds"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		if resp.Leds.RGBW != rgbw || resp.Leds.WV != rgbw {
+			t.Errorf("RGBW %v: leds.rgbw = %v, leds.wv = %v", rgbw, resp.Leds.RGBW, resp.Leds.WV)
+		}
+	}
+}
+
+func TestInfoUptimeAndResetReason(t *testing.T) {
+	srv := NewServer(":0", state.NewLEDState(testLEDs, "#000000"), testDDPPort, testGeometry)
+	srv.SetResetReason("Watchdog")
+
+	get := func() (uptime int, reason string) {
+		w := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+		var resp struct {
+			Uptime      int    `json:"uptime"`
+			ResetReason string `json:"resetReason"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		return resp.Uptime, resp.ResetReason
+	}
+
+	first, reason := get()
+	if first < 0 {
+		t.Errorf("uptime = %d, want >= 0", first)
+	}
+	if reason != "Watchdog" {
+		t.Errorf("resetReason = %q, want Watchdog", reason)
+	}
+
+	// Uptime is in whole seconds
+	time.Sleep(1100 * time.Millisecond)
+	if second, _ := get(); second <= first {
+		t.Errorf("uptime went from %d to %d, want it to increase", first, second)
+	}
+
+	srv.SetResetReason("")
+	if _, reason := get(); reason != DefaultResetReason {
+		t.Errorf("resetReason = %q, want the default %q", reason, DefaultResetReason)
+	}
+}
+
 func TestGetJSON(t *testing.T) {
 	ledState := state.NewLEDState(testLEDs, "#000000")
-	srv := NewServer(":0", ledState, testDDPPort)
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
 
 	r := gin.Default()
 	r.GET("/json", srv.handleGetJSON)
@@ -137,7 +358,7 @@ func TestGetJSON(t *testing.T) {
 
 func TestLiveFieldWithDDPActivity(t *testing.T) {
 	ledState := state.NewLEDState(testLEDs, "#000000")
-	srv := NewServer(":0", ledState, testDDPPort)
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
 
 	r := gin.Default()
 	r.GET("/json/info", srv.handleGetInfo)
@@ -164,6 +385,122 @@ func TestLiveFieldWithDDPActivity(t *testing.T) {
 	}
 }
 
+func TestConnectedWithHeartbeat(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	ddpServer := ddp.NewServer(testDDPPort, ledState)
+
+	r := gin.Default()
+	r.GET("/json/info", srv.handleGetInfo)
+	get := func() testInfo {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/info", nil))
+		var resp testInfo
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("bad JSON: %v", err)
+		}
+		return resp
+	}
+
+	if resp := get(); resp.Connected || resp.Live {
+		t.Fatalf("expected connected=false live=false initially, got %+v", resp)
+	}
+
+	// A query heartbeat carries no pixels
+	if err := ddpServer.ServeReader(strings.NewReader(string(ddptest.Stream(ddptest.QueryPacket())))); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+	if resp := get(); !resp.Connected || resp.Live {
+		t.Errorf("after heartbeat got connected=%v live=%v, want true false", resp.Connected, resp.Live)
+	}
+
+	frame := ddptest.RGBFrame(1, 0, []color.RGBA{{255, 0, 0, 255}})
+	if err := ddpServer.ServeReader(strings.NewReader(string(ddptest.Stream(frame)))); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+	if resp := get(); !resp.Connected || !resp.Live {
+		t.Errorf("after pixel data got connected=%v live=%v, want true true", resp.Connected, resp.Live)
+	}
+}
+
+func TestGetStateMsgPack(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	ledState.SetBrightness(42)
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.GET("/json/state", srv.handleGetState)
+
+	req := httptest.NewRequest(http.MethodGet, "/json/state", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/msgpack") {
+		t.Fatalf("expected msgpack Content-Type, got %s", ct)
+	}
+
+	var resp testState
+	var mh codec.MsgpackHandle
+	mh.RawToString = true
+	if err := codec.NewDecoderBytes(w.Body.Bytes(), &mh).Decode(&resp); err != nil {
+		t.Fatalf("bad msgpack: %v", err)
+	}
+	if !resp.On {
+		t.Errorf("expected power on by default")
+	}
+	if resp.Bri != 42 {
+		t.Errorf("bri = %d, want 42", resp.Bri)
+	}
+
+	// Without an Accept header the response stays JSON
+	req = httptest.NewRequest(http.MethodGet, "/json/state", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, "application/json") {
+		t.Errorf("expected JSON Content-Type by default, got %s", ct)
+	}
+}
+
+func TestGetGeometry(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.GET("/json/geometry", srv.handleGetGeometry)
+
+	req := httptest.NewRequest(http.MethodGet, "/json/geometry", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+
+	var resp struct {
+		Rows   int    `json:"rows"`
+		Cols   int    `json:"cols"`
+		Wiring string `json:"wiring"`
+		Count  int    `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+
+	if resp.Rows != 10 || resp.Cols != 2 {
+		t.Errorf("geometry = %dx%d, want 10x2", resp.Rows, resp.Cols)
+	}
+	if resp.Wiring != "row" {
+		t.Errorf("wiring = %q, want %q", resp.Wiring, "row")
+	}
+	if resp.Count != 20 {
+		t.Errorf("count = %d, want 20", resp.Count)
+	}
+}
+
 func TestMACAddressGeneration(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -205,7 +542,7 @@ func TestMACAddressGeneration(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ledState := state.NewLEDState(tt.ledCount, "#000000")
-			srv := NewServer(tt.httpAddr, ledState, tt.ddpPort)
+			srv := NewServer(tt.httpAddr, ledState, tt.ddpPort, Geometry{Rows: 1, Cols: tt.ledCount, Wiring: "row"})
 
 			// Test MAC in /json/info endpoint
 			r := gin.Default()
@@ -250,7 +587,7 @@ func TestPortCollision(t *testing.T) {
 	ledState := state.NewLEDState(testLEDs, "#000000")
 
 	// Start first server
-	srv1 := NewServer(testPort, ledState, testDDPPort)
+	srv1 := NewServer(testPort, ledState, testDDPPort, testGeometry)
 	errChan1 := make(chan error, 1)
 	go func() {
 		err := srv1.Start()
@@ -268,7 +605,7 @@ func TestPortCollision(t *testing.T) {
 	}
 
 	// Try to start second server on same port
-	srv2 := NewServer(testPort, ledState, testDDPPort)
+	srv2 := NewServer(testPort, ledState, testDDPPort, testGeometry)
 	errChan2 := make(chan error, 1)
 	go func() {
 		err := srv2.Start()
@@ -294,13 +631,135 @@ func TestPortCollision(t *testing.T) {
 	srv2.Stop()
 }
 
+func TestMethodNotAllowed(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	events, unsubscribe := ledState.SubscribeActivity(4)
+	defer unsubscribe()
+
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/json/state", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", w.Code)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), `{"error":"Method not allowed"}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected activity %+v for a 405", ev)
+	default:
+	}
+}
+
+func TestPostStateLEDMap(t *testing.T) {
+	ledState := state.NewLEDState(3, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 3, Wiring: "row"})
+	ddpServer := ddp.NewServer(testDDPPort, ledState)
+	ddpServer.SetLEDMaps(map[int][]int{0: {0, 1, 2}, 1: {2, 1, 0}})
+	srv.SetDDPServer(ddpServer)
+	handler := srv.Handler()
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	red, black := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 0, 255}
+	send := func(seq uint8) {
+		ledState.SetLEDs([]color.RGBA{black, black, black})
+		frame := ddptest.RGBFrame(seq, 0, []color.RGBA{red})
+		if err := ddpServer.ServeReader(bytes.NewReader(ddptest.Stream(frame))); err != nil {
+			t.Fatalf("ServeReader failed: %v", err)
+		}
+	}
+
+	send(1)
+	if got := ledState.LEDs()[0]; got != red {
+		t.Errorf("map 0: LED 0 = %v, want red", got)
+	}
+
+	if code := post(`{"ledmap":1}`); code >= 300 {
+		t.Fatalf("selecting ledmap 1 returned %d", code)
+	}
+	send(2)
+	if leds := ledState.LEDs(); leds[0] != black || leds[2] != red {
+		t.Errorf("map 1: LEDs = %v, want index 0 routed to LED 2", leds)
+	}
+
+	if code := post(`{"ledmap":5}`); code != http.StatusBadRequest {
+		t.Errorf("unloaded ledmap returned %d, want 400", code)
+	}
+	if got := ddpServer.LEDMap(); got != 1 {
+		t.Errorf("LEDMap = %d, want 1 kept after a rejected switch", got)
+	}
+}
+
+func TestPostStateMainSeg(t *testing.T) {
+	ledState := state.NewLEDState(10, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 10, Wiring: "row"})
+	handler := srv.Handler()
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(`{"seg":[{"id":0,"start":0,"stop":5},{"id":1,"start":5,"stop":10}]}`); code >= 300 {
+		t.Fatalf("creating segments returned %d", code)
+	}
+	if code := post(`{"mainseg":1}`); code >= 300 {
+		t.Fatalf("setting mainseg returned %d", code)
+	}
+	if code := post(`{"seg":{"col":[[255,0,0]]}}`); code >= 300 {
+		t.Fatalf("colouring the main segment returned %d", code)
+	}
+
+	red, black := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 0, 255}
+	for i, got := range ledState.LEDs() {
+		want := black
+		if i >= 5 {
+			want = red
+		}
+		if got != want {
+			t.Errorf("LED %d = %v, want %v", i, got, want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/state", nil))
+	var resp struct {
+		MainSeg int `json:"mainseg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.MainSeg != 1 {
+		t.Errorf("mainseg = %d (%v), want 1", resp.MainSeg, err)
+	}
+
+	if code := post(`{"mainseg":7}`); code != http.StatusBadRequest {
+		t.Errorf("mainseg for a missing segment returned %d, want 400", code)
+	}
+
+	// A segment created in the same request can be made the main one
+	if code := post(`{"seg":[{"id":2,"start":0,"stop":5}],"mainseg":2}`); code >= 300 {
+		t.Errorf("creating and selecting a segment returned %d", code)
+	}
+	if got := ledState.MainSegment(); got != 2 {
+		t.Errorf("mainseg = %d, want 2", got)
+	}
+}
+
 func TestNoRouteHandler(t *testing.T) {
 	// Use a specific port for testing
 	const testPort = ":8082"
 	ledState := state.NewLEDState(testLEDs, "#000000")
 
 	// Start server
-	srv := NewServer(testPort, ledState, testDDPPort)
+	srv := NewServer(testPort, ledState, testDDPPort, testGeometry)
 	errChan := make(chan error, 1)
 	go func() {
 		err := srv.Start()
@@ -404,3 +863,742 @@ func TestNoRouteHandler(t *testing.T) {
 		t.Errorf("Failed to stop server: %v", err)
 	}
 }
+
+func TestRestartOnNewPort(t *testing.T) {
+	const firstPort = ":8083"
+	const secondPort = ":8084"
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(firstPort, ledState, testDDPPort, testGeometry)
+
+	get := func(addr string) (int, error) {
+		resp, err := http.Get("http://localhost" + addr + "/json/info")
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	if err := srv.Start(); err != nil {
+		t.Fatalf("first Start failed: %v", err)
+	}
+	if code, err := get(firstPort); err != nil || code != http.StatusOK {
+		t.Fatalf("expected 200 on first port, got %d (%v)", code, err)
+	}
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	srv.SetAddress(secondPort)
+	if err := srv.Start(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	defer srv.Stop()
+
+	if code, err := get(secondPort); err != nil || code != http.StatusOK {
+		t.Fatalf("expected 200 on second port, got %d (%v)", code, err)
+	}
+	if _, err := get(firstPort); err == nil {
+		t.Error("expected first port to be closed after restart")
+	}
+
+	// MAC should reflect the new HTTP port (8084 = 0x1F94)
+	if want := "WL:ED:94:D0:00:14"; srv.macAddr != want {
+		t.Errorf("MAC after restart = %q, want %q", srv.macAddr, want)
+	}
+}
+
+func TestGetLastFrame(t *testing.T) {
+	const ddpPort = 4060
+	ledState := state.NewLEDState(2, "#000000")
+	ddpServer := ddp.NewServer(ddpPort, ledState)
+	if err := ddpServer.Start(); err != nil {
+		t.Fatalf("DDP server failed to start: %v", err)
+	}
+	defer ddpServer.Stop()
+
+	srv := NewServer(":0", ledState, ddpPort, Geometry{Rows: 1, Cols: 2, Wiring: "row"})
+	srv.SetDDPServer(ddpServer)
+
+	r := gin.Default()
+	r.GET("/json/lastframe", srv.handleGetLastFrame)
+
+	// No frame yet
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/lastframe", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404 before any frame, got %d", w.Code)
+	}
+
+	// Version 1 + push, sequence 5, RGB 8-bit, device 1, offset 0, length 6
+	packet := []byte{0x41, 0x05, 0x0B, 0x01, 0, 0, 0, 0, 0, 6, 255, 0, 0, 0, 0, 255}
+	conn, err := net.Dial("udp", "127.0.0.1:4060")
+	if err != nil {
+		t.Fatalf("failed to dial DDP server: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(packet); err != nil {
+		t.Fatalf("failed to send DDP packet: %v", err)
+	}
+
+	var resp struct {
+		LEDs   [][]int `json:"leds"`
+		Source string  `json:"source"`
+		Seq    int     `json:"seq"`
+	}
+	deadline := time.Now().Add(time.Second)
+	for {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/lastframe", nil))
+		if w.Code == http.StatusOK || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200 after frame, got %d", w.Code)
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+
+	if resp.Seq != 5 {
+		t.Errorf("seq = %d, want 5", resp.Seq)
+	}
+	if len(resp.LEDs) != 2 || resp.LEDs[0][0] != 255 || resp.LEDs[1][2] != 255 {
+		t.Errorf("unexpected leds: %v", resp.LEDs)
+	}
+	if !strings.HasPrefix(resp.Source, "127.0.0.1:") {
+		t.Errorf("source = %q, want 127.0.0.1:<port>", resp.Source)
+	}
+}
+
+func TestPostStateSegmentOff(t *testing.T) {
+	ledState := state.NewLEDState(10, "#FF0000")
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 10, Wiring: "row"})
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Split into two segments, then turn the second one off
+	if code := post(`{"seg":[{"start":0,"stop":5},{"start":5,"stop":10}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204 creating segments, got %d", code)
+	}
+	if code := post(`{"seg":[{},{"on":false}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204 turning segment off, got %d", code)
+	}
+
+	red := color.RGBA{255, 0, 0, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	rendered := ledState.RenderedLEDs()
+	for i, c := range rendered {
+		want := red
+		if i >= 5 {
+			want = black
+		}
+		if c != want {
+			t.Errorf("rendered[%d] = %v, want %v", i, c, want)
+		}
+	}
+
+	// Stored colors are untouched
+	if ledState.LEDs()[7] != red {
+		t.Errorf("stored LED 7 = %v, want %v", ledState.LEDs()[7], red)
+	}
+
+	// Out of range segments are rejected
+	if code := post(`{"seg":[{"start":0,"stop":11}]}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for out of range segment, got %d", code)
+	}
+}
+
+func TestPostStatePartialColorArrays(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want color.RGBA
+	}{
+		{"one element is grayscale", `{"seg":[{"col":[[128]]}]}`, color.RGBA{128, 128, 128, 255}},
+		{"two elements are RG", `{"seg":[{"col":[[255,64]]}]}`, color.RGBA{255, 64, 0, 255}},
+		{"three elements are RGB", `{"seg":[{"col":[[1,2,3]]}]}`, color.RGBA{1, 2, 3, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledState := state.NewLEDState(testLEDs, "#000000")
+			srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+			r := gin.Default()
+			r.POST("/json/state", srv.handlePostState)
+
+			req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("expected status 204, got %d", w.Code)
+			}
+			for i, c := range ledState.LEDs() {
+				if c != tt.want {
+					t.Fatalf("LED %d = %v, want %v", i, c, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPostStateTransitionOneShot(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	post := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("expected status 204, got %d", w.Code)
+		}
+	}
+
+	// The stored default is an instant change
+	post(`{"bri":0,"transition":0}`)
+
+	// tt fades over 300ms for this request only
+	post(`{"bri":200,"tt":3}`)
+	if b := ledState.Brightness(); b == 200 {
+		t.Error("expected brightness to still be fading right after a tt request")
+	}
+	time.Sleep(400 * time.Millisecond)
+	if b := ledState.Brightness(); b != 200 {
+		t.Errorf("brightness = %d after tt elapsed, want 200", b)
+	}
+	if d := ledState.Transition(); d != 0 {
+		t.Errorf("tt changed the stored transition to %v", d)
+	}
+
+	// Without tt the stored default applies again
+	post(`{"bri":50}`)
+	if b := ledState.Brightness(); b != 50 {
+		t.Errorf("brightness = %d, want 50 immediately without tt", b)
+	}
+}
+
+func TestPostStateRelativeBrightness(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"relative increase", `{"bri":"~10"}`, 110},
+		{"relative decrease", `{"bri":"~-20"}`, 80},
+		{"clamped at 255", `{"bri":"~200"}`, 255},
+		{"clamped at 0", `{"bri":"~-200"}`, 0},
+		{"absolute integer", `{"bri":42}`, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledState := state.NewLEDState(testLEDs, "#000000")
+			ledState.SetBrightness(100)
+			srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+			r := gin.Default()
+			r.POST("/json/state", srv.handlePostState)
+
+			req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNoContent {
+				t.Fatalf("expected status 204, got %d", w.Code)
+			}
+			if b := ledState.Brightness(); b != tt.want {
+				t.Errorf("brightness = %d, want %d", b, tt.want)
+			}
+		})
+	}
+
+	// Malformed relative values are rejected
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"bri":"10"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for malformed bri, got %d", w.Code)
+	}
+}
+
+func TestPostStateSegmentByID(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	r.GET("/json/state", srv.handleGetState)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Shrink id 0 and create id 2 over the rest of the strip
+	if code := post(`{"seg":[{"id":0,"stop":10},{"id":2,"start":10,"stop":20}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	// Update id 2 alone; being first in the array must not address id 0
+	if code := post(`{"seg":[{"id":2,"on":false}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+
+	segs := ledState.Segments()
+	if len(segs) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(segs))
+	}
+	if !reflect.DeepEqual(segs[0], state.Segment{ID: 0, Start: 0, Stop: 10, On: true, Col: color.RGBA{A: 255}}) {
+		t.Errorf("segment id 0 = %+v, want untouched", segs[0])
+	}
+	if !reflect.DeepEqual(segs[1], state.Segment{ID: 2, Start: 10, Stop: 20, On: false, Col: color.RGBA{A: 255}}) {
+		t.Errorf("segment id 2 = %+v", segs[1])
+	}
+
+	// The ids are reported back in /json/state
+	req := httptest.NewRequest(http.MethodGet, "/json/state", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp struct {
+		Seg []struct {
+			ID int  `json:"id"`
+			On bool `json:"on"`
+		} `json:"seg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Seg) != 2 || resp.Seg[0].ID != 0 || resp.Seg[1].ID != 2 || resp.Seg[1].On {
+		t.Errorf("unexpected segments in state: %+v", resp.Seg)
+	}
+
+	// An unknown id needs a range to be created
+	if code := post(`{"seg":[{"id":5,"on":true}]}`); code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown id without range, got %d", code)
+	}
+}
+
+func TestPostStateSegmentFreeze(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"seg":[{"stop":10,"frz":true}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+
+	if !ledState.Segments()[0].Frz {
+		t.Fatal("expected segment 0 to be frozen")
+	}
+	ledState.SetLED(0, color.RGBA{255, 0, 0, 255})
+	ledState.SetLED(15, color.RGBA{255, 0, 0, 255})
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("frozen LED 0 = %v, want unchanged black", got)
+	}
+	if got := ledState.LEDs()[15]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("LED 15 outside the frozen segment = %v, want red", got)
+	}
+}
+
+func TestHeadState(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.HEAD("/json/state", headOnly(srv.handleGetState))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/json/state", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	select {
+	case ev := <-ledState.ActivityChannel():
+		t.Errorf("unexpected activity event %+v", ev)
+	default:
+	}
+}
+
+func TestPostStateNightlight(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	r.GET("/json/state", srv.handleGetState)
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"nl":{"on":true,"dur":2,"mode":0}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/state", nil))
+	var resp struct {
+		NL struct {
+			On   bool `json:"on"`
+			Dur  int  `json:"dur"`
+			Mode int  `json:"mode"`
+			Rem  int  `json:"rem"`
+		} `json:"nl"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if !resp.NL.On || resp.NL.Dur != 2 || resp.NL.Mode != 0 {
+		t.Errorf("nl = %+v, want on with dur 2 and mode 0", resp.NL)
+	}
+	if resp.NL.Rem < 119 || resp.NL.Rem > 120 {
+		t.Errorf("nl.rem = %d, want about 120 seconds", resp.NL.Rem)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"nl":{"mode":7}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid mode returned %d, want 400", w.Code)
+	}
+	ledState.SetNightlight(state.Nightlight{})
+}
+
+func TestGetHistory(t *testing.T) {
+	ledState := state.NewLEDState(1, "#000000")
+	ddpServer := ddp.NewServer(testDDPPort, ledState)
+	ddpServer.SetHistory(8)
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 1, Wiring: "row"})
+	srv.SetDDPServer(ddpServer)
+
+	r := gin.Default()
+	r.GET("/json/history", srv.handleGetHistory)
+
+	// Commit three frames
+	var stream []byte
+	for seq := uint8(1); seq <= 3; seq++ {
+		stream = append(stream, ddptest.Stream(ddptest.RGBFrame(seq, 0, []color.RGBA{{seq * 10, 0, 0, 255}}))...)
+	}
+	if err := ddpServer.ServeReader(strings.NewReader(string(stream))); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/history?n=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Frames []struct {
+			LEDs [][]int `json:"leds"`
+			Seq  int     `json:"seq"`
+		} `json:"frames"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if len(resp.Frames) != 3 {
+		t.Fatalf("got %d frames, want 3", len(resp.Frames))
+	}
+	for i, f := range resp.Frames {
+		if f.Seq != i+1 || f.LEDs[0][0] != (i+1)*10 {
+			t.Errorf("frame %d = seq %d leds %v, want seq %d red %d", i, f.Seq, f.LEDs, i+1, (i+1)*10)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/history?n=abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid n returned %d, want 400", w.Code)
+	}
+}
+
+func TestPostStateSegmentPalette(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(`{"seg":[{"fx":46,"pal":1}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	stops := state.Palettes[1].Stops
+	rendered := ledState.RenderedLEDs()
+	if rendered[0] != stops[0] {
+		t.Errorf("rendered[0] = %v, want first Sunset stop %v", rendered[0], stops[0])
+	}
+	if last := rendered[testLEDs-1]; last != stops[len(stops)-1] {
+		t.Errorf("rendered[%d] = %v, want last Sunset stop %v", testLEDs-1, last, stops[len(stops)-1])
+	}
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("stored LED 0 = %v, want unchanged black", got)
+	}
+
+	if code := post(`{"seg":[{"pal":99}]}`); code != http.StatusBadRequest {
+		t.Errorf("unknown palette: expected status 400, got %d", code)
+	}
+	if code := post(`{"seg":[{"fx":7}]}`); code != http.StatusBadRequest {
+		t.Errorf("unknown effect: expected status 400, got %d", code)
+	}
+}
+
+func TestPostStateCustomPalette(t *testing.T) {
+	ledState := state.NewLEDState(3, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, Geometry{Rows: 1, Cols: 3, Wiring: "row"})
+	handler := srv.Handler()
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(`{"seg":[{"fx":46,"cpal":[[255,0,0],[0,0,255]]}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	rendered := ledState.RenderedLEDs()
+	if rendered[0] != (color.RGBA{255, 0, 0, 255}) || rendered[2] != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("ends = %v and %v, want red and blue", rendered[0], rendered[2])
+	}
+	// Halfway along a red to blue gradient is purple
+	if mid := rendered[1]; mid.R < 100 || mid.B < 100 || mid.G != 0 {
+		t.Errorf("midpoint = %v, want purple", mid)
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/state", nil))
+	if !strings.Contains(w.Body.String(), `"cpal":[[255,0,0],[0,0,255]]`) {
+		t.Errorf("state does not report the custom palette: %s", w.Body)
+	}
+
+	// Clearing it goes back to the indexed palette
+	if code := post(`{"seg":[{"cpal":[]}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	if cpal := ledState.Segments()[0].CPal; cpal != nil {
+		t.Errorf("CPal = %v after clearing, want nil", cpal)
+	}
+
+	if code := post(`{"seg":[{"cpal":[[255,0,0],[]]}]}`); code != http.StatusBadRequest {
+		t.Errorf("empty stop: expected status 400, got %d", code)
+	}
+}
+
+func TestPostStateEchoesSegmentColor(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	r.GET("/json/state", srv.handleGetState)
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"seg":[{"col":[[255,128,0]]}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/state", nil))
+	var resp struct {
+		Seg []struct {
+			Col [][]int `json:"col"`
+		} `json:"seg"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("bad JSON: %v", err)
+	}
+	if len(resp.Seg) != 1 || len(resp.Seg[0].Col) != 3 {
+		t.Fatalf("expected one segment with three colours, got %+v", resp.Seg)
+	}
+	if got := resp.Seg[0].Col[0]; len(got) != 3 || got[0] != 255 || got[1] != 128 || got[2] != 0 {
+		t.Errorf("seg[0].col[0] = %v, want [255 128 0]", got)
+	}
+}
+
+func TestPostStateSegmentLen(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	post := func(body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := post(`{"seg":[{"start":2,"len":5,"col":[[255,0,0]]}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	if seg := ledState.Segments()[0]; seg.Start != 2 || seg.Stop != 7 {
+		t.Errorf("segment range = %d-%d, want 2-7", seg.Start, seg.Stop)
+	}
+	red := color.RGBA{255, 0, 0, 255}
+	for i, c := range ledState.LEDs()[:8] {
+		if covered := i >= 2 && i <= 6; (c == red) != covered {
+			t.Errorf("LED %d = %v, covered by segment %v", i, c, covered)
+		}
+	}
+
+	// stop wins over len when both are sent
+	if code := post(`{"seg":[{"start":0,"stop":4,"len":9}]}`); code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", code)
+	}
+	if seg := ledState.Segments()[0]; seg.Stop != 4 {
+		t.Errorf("segment stop = %d, want 4 from stop", seg.Stop)
+	}
+
+	if code := post(`{"seg":[{"start":2,"len":100}]}`); code != http.StatusBadRequest {
+		t.Errorf("len past the last LED: expected status 400, got %d", code)
+	}
+}
+
+func TestPostStateIdentify(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"identify":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d", w.Code)
+	}
+
+	white := color.RGBA{255, 255, 255, 255}
+	deadline := time.Now().Add(2 * time.Second)
+	for ledState.RenderedLEDs()[0] != white {
+		if time.Now().After(deadline) {
+			t.Fatalf("rendered LED 0 = %v, want white while identifying", ledState.RenderedLEDs()[0])
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("stored LED 0 = %v, want unchanged black", got)
+	}
+}
+
+func TestPostStateColorWhileLive(t *testing.T) {
+	ledState := state.NewLEDState(testLEDs, "#000000")
+	srv := NewServer(":0", ledState, testDDPPort, testGeometry)
+
+	r := gin.Default()
+	r.POST("/json/state", srv.handlePostState)
+	r.GET("/json/state", srv.handleGetState)
+	post := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("POST %s: expected status 204, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+
+	// A DDP frame arrives and the device goes live
+	blue := color.RGBA{0, 0, 255, 255}
+	ledState.SetLive()
+	ledState.SetLED(0, blue)
+
+	// DDP takes precedence over a solid colour from the API
+	before := ledState.Segments()[0].Col
+	post(`{"seg":[{"col":[[255,0,0]]}]}`)
+	if got := ledState.LEDs()[0]; got != blue {
+		t.Errorf("LED 0 = %v, want the DDP colour %v", got, blue)
+	}
+	if got := ledState.Segments()[0].Col; got != before {
+		t.Errorf("segment col = %v, want %v kept while the fill was refused", got, before)
+	}
+
+	// With live override, the API colour is shown
+	post(`{"lor":1,"seg":[{"col":[[255,0,0]]}]}`)
+	if got := ledState.LEDs()[0]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v, want red with lor 1", got)
+	}
+	if got := ledState.Segments()[0].Col; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("segment col = %v, want red with lor 1", got)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/json/state", nil))
+	var got struct {
+		LOR int `json:"lor"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid state JSON: %v", err)
+	}
+	if got.LOR != 1 {
+		t.Errorf("lor = %d, want 1", got.LOR)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/json/state", strings.NewReader(`{"lor":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("lor 3: expected status 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,200 @@
+// Package apiclient is a small client for the simulator's WLED JSON API, so
+// integration tests and tools can drive a running instance without building
+// requests and decoding responses by hand.
+package apiclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Client talks to one simulator's JSON API
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New returns a client for the API at baseURL, such as
+// "http://127.0.0.1:8080"
+func New(baseURL string) *Client {
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// State is the state object returned by GET /json/state
+type State struct {
+	On         bool       `json:"on"`
+	Bri        int        `json:"bri"`
+	Transition int        `json:"transition"` // 100ms units
+	Live       bool       `json:"live"`
+	LOR        int        `json:"lor"`     // Live override mode
+	MainSeg    int        `json:"mainseg"` // Segment a seg object without an id applies to
+	NL         Nightlight `json:"nl"`
+	Seg        []Segment  `json:"seg"`
+}
+
+// Nightlight is the nl object of the state
+type Nightlight struct {
+	On   bool `json:"on"`
+	Dur  int  `json:"dur"` // Minutes
+	Mode int  `json:"mode"`
+	TBri int  `json:"tbri"`
+	Rem  int  `json:"rem"` // Seconds left, -1 when not running
+}
+
+// Segment is one entry of the state's seg array
+type Segment struct {
+	ID    int     `json:"id"`
+	Start int     `json:"start"`
+	Stop  int     `json:"stop"`
+	Len   int     `json:"len"`
+	On    bool    `json:"on"`
+	Bri   int     `json:"bri"`
+	CCT   int     `json:"cct"`
+	Rev   bool    `json:"rev"`
+	Frz   bool    `json:"frz"`
+	FX    int     `json:"fx"`
+	Pal   int     `json:"pal"`
+	CPal  [][]int `json:"cpal"` // Custom palette stops, nil when none
+	Col   [][]int `json:"col"`  // Primary, secondary and tertiary [r,g,b]
+}
+
+// StateUpdate is a POST /json/state body. Nil fields are left unchanged.
+type StateUpdate struct {
+	On         *bool             `json:"on,omitempty"`
+	Bri        *int              `json:"bri,omitempty"`
+	Transition *int              `json:"transition,omitempty"` // 100ms units
+	TT         *int              `json:"tt,omitempty"`         // Transition for this request only
+	NL         *NightlightUpdate `json:"nl,omitempty"`
+	Seg        []SegmentUpdate   `json:"seg,omitempty"`
+	Identify   bool              `json:"identify,omitempty"`
+	LOR        *int              `json:"lor,omitempty"` // 0 live data wins, 1 override until the stream ends, 2 always
+	MainSeg    *int              `json:"mainseg,omitempty"`
+}
+
+// NightlightUpdate changes the nightlight. Nil fields are left unchanged.
+type NightlightUpdate struct {
+	On   *bool `json:"on,omitempty"`
+	Dur  *int  `json:"dur,omitempty"`
+	Mode *int  `json:"mode,omitempty"`
+	TBri *int  `json:"tbri,omitempty"`
+}
+
+// SegmentUpdate changes or creates a segment. Segments are matched by ID
+// when set, and by their position in the seg array otherwise.
+type SegmentUpdate struct {
+	ID    *int    `json:"id,omitempty"`
+	Start *int    `json:"start,omitempty"`
+	Stop  *int    `json:"stop,omitempty"`
+	Len   *int    `json:"len,omitempty"`
+	On    *bool   `json:"on,omitempty"`
+	Bri   *int    `json:"bri,omitempty"`
+	CCT   *int    `json:"cct,omitempty"`
+	Rev   *bool   `json:"rev,omitempty"`
+	Frz   *bool   `json:"frz,omitempty"`
+	FX    *int    `json:"fx,omitempty"`
+	Pal   *int    `json:"pal,omitempty"`
+	CPal  [][]int `json:"cpal,omitempty"`
+	Col   [][]int `json:"col,omitempty"`
+}
+
+// Info is the info object returned by GET /json/info
+type Info struct {
+	Ver       string `json:"ver"`
+	Vid       int    `json:"vid"` // Zero when not configured
+	IP        string `json:"ip"`
+	Name      string `json:"name"`
+	MAC       string `json:"mac"`
+	Live      bool   `json:"live"`
+	Connected bool   `json:"connected"`
+	Leds      struct {
+		Count  int `json:"count"`
+		Pwr    int `json:"pwr"` // Estimated current in mA
+		Matrix *struct {
+			W int `json:"w"`
+			H int `json:"h"`
+		} `json:"matrix"` // Nil for strips
+	} `json:"leds"`
+}
+
+// Bool returns a pointer to b, for optional update fields
+func Bool(b bool) *bool { return &b }
+
+// Int returns a pointer to n, for optional update fields
+func Int(n int) *int { return &n }
+
+// GetState fetches the current state
+func (c *Client) GetState() (*State, error) {
+	var st State
+	if err := c.get("/json/state", &st); err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+// GetInfo fetches the device info
+func (c *Client) GetInfo() (*Info, error) {
+	var info Info
+	if err := c.get("/json/info", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SetState applies update
+func (c *Client) SetState(update StateUpdate) error {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Post(c.baseURL+"/json/state", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus("POST /json/state", resp)
+}
+
+// get decodes the JSON response to GET path into out
+func (c *Client) get(path string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus("GET "+path, resp); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("GET %s: invalid response: %v", path, err)
+	}
+	return nil
+}
+
+// checkStatus turns an error response into an error carrying the server's
+// message
+func checkStatus(op string, resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		return fmt.Errorf("%s: %s: %s", op, resp.Status, body.Error)
+	}
+	return fmt.Errorf("%s: %s", op, resp.Status)
+}
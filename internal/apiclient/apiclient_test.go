@@ -0,0 +1,100 @@
+package apiclient
+
+import (
+	"image/color"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/api"
+	"wled-simulator/internal/state"
+)
+
+// newTestClient serves a fresh simulator API in-process and returns a client
+// for it along with the state it drives
+func newTestClient(t *testing.T) (*Client, *state.LEDState) {
+	t.Helper()
+	st := state.NewLEDState(16, "#000000")
+	srv := api.NewServer(":0", st, 4048, api.Geometry{Rows: 4, Cols: 4, Wiring: "row"})
+	hs := httptest.NewServer(srv.Handler())
+	t.Cleanup(hs.Close)
+	return New(hs.URL + "/"), st
+}
+
+func TestSetAndGetState(t *testing.T) {
+	c, st := newTestClient(t)
+
+	err := c.SetState(StateUpdate{
+		On:  Bool(true),
+		Bri: Int(128),
+		Seg: []SegmentUpdate{{Col: [][]int{{255, 0, 0}}}},
+	})
+	if err != nil {
+		t.Fatalf("SetState failed: %v", err)
+	}
+	if got := st.LEDs()[0]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v, want red", got)
+	}
+
+	got, err := c.GetState()
+	if err != nil {
+		t.Fatalf("GetState failed: %v", err)
+	}
+	if !got.On || got.Bri != 128 {
+		t.Errorf("state on=%v bri=%d, want on=true bri=128", got.On, got.Bri)
+	}
+	if len(got.Seg) != 1 || got.Seg[0].Len != 16 {
+		t.Fatalf("seg = %+v, want one segment of 16 LEDs", got.Seg)
+	}
+	if col := got.Seg[0].Col[0]; len(col) != 3 || col[0] != 255 || col[1] != 0 || col[2] != 0 {
+		t.Errorf("seg col = %v, want [255 0 0]", col)
+	}
+	if got.NL.Rem != -1 {
+		t.Errorf("nl.rem = %d, want -1 while off", got.NL.Rem)
+	}
+}
+
+func TestSetStateOmitsUnsetFields(t *testing.T) {
+	c, st := newTestClient(t)
+	st.SetBrightness(42)
+
+	if err := c.SetState(StateUpdate{On: Bool(false)}); err != nil {
+		t.Fatalf("SetState failed: %v", err)
+	}
+	if st.Power() {
+		t.Error("power still on")
+	}
+	if st.Brightness() != 42 {
+		t.Errorf("brightness = %d, want 42 left unchanged", st.Brightness())
+	}
+}
+
+func TestSetStateReportsServerError(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	err := c.SetState(StateUpdate{Seg: []SegmentUpdate{{FX: Int(999)}}})
+	if err == nil {
+		t.Fatal("expected an error for an unknown effect")
+	}
+	if !strings.Contains(err.Error(), "400") || !strings.Contains(err.Error(), "unknown effect 999") {
+		t.Errorf("error = %q, want the status and server message", err)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	info, err := c.GetInfo()
+	if err != nil {
+		t.Fatalf("GetInfo failed: %v", err)
+	}
+	if info.Ver != api.DefaultVersion || info.Leds.Count != 16 {
+		t.Errorf("info ver=%q count=%d, want %q and 16", info.Ver, info.Leds.Count, api.DefaultVersion)
+	}
+	if info.Leds.Matrix == nil || info.Leds.Matrix.W != 4 || info.Leds.Matrix.H != 4 {
+		t.Errorf("info matrix = %+v, want 4x4", info.Leds.Matrix)
+	}
+	if info.MAC == "" {
+		t.Error("info mac is empty")
+	}
+}
@@ -0,0 +1,86 @@
+// Package ascii prints LED frames as text, one character per LED laid out
+// like the matrix, for watching the simulator from a terminal when headless.
+package ascii
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image/color"
+	"io"
+	"slices"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+// MinInterval is the shortest time allowed between printed frames, so a fast
+// DDP stream can't flood the terminal
+const MinInterval = 100 * time.Millisecond
+
+// ramp holds characters from dark to bright, picked by the largest channel
+const ramp = " .:-=+*#%@"
+
+// Render writes leds as rows x cols panels in order, each row on its own line
+// and panels separated by a blank line. wiring is "row" or "col", as for the
+// GUI. With ansi, each character is coloured with a 24-bit escape sequence.
+func Render(w io.Writer, leds []color.RGBA, rows, cols int, wiring string, ansi bool) error {
+	size := rows * cols
+	if size <= 0 {
+		return nil
+	}
+	bw := bufio.NewWriter(w)
+	for start := 0; start < len(leds); start += size {
+		if start > 0 {
+			bw.WriteByte('\n')
+		}
+		panel := leds[start:min(start+size, len(leds))]
+		for row := 0; row < rows; row++ {
+			for col := 0; col < cols; col++ {
+				i := row*cols + col
+				if wiring == "col" {
+					i = col*rows + row
+				}
+				if i >= len(panel) {
+					bw.WriteByte(' ')
+					continue
+				}
+				c := panel[i]
+				if ansi {
+					fmt.Fprintf(bw, "\x1b[38;2;%d;%d;%dm", c.R, c.G, c.B)
+				}
+				bw.WriteByte(ramp[int(max(c.R, c.G, c.B))*len(ramp)/256])
+			}
+			if ansi {
+				bw.WriteString("\x1b[0m")
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// Run renders the displayed LEDs to w every interval, at least MinInterval,
+// skipping frames that haven't changed, until ctx is cancelled
+func Run(ctx context.Context, w io.Writer, st *state.LEDState, rows, cols int, wiring string, interval time.Duration, ansi bool) {
+	ticker := time.NewTicker(max(interval, MinInterval))
+	defer ticker.Stop()
+
+	var last []color.RGBA
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		leds := st.RenderedLEDs()
+		if slices.Equal(leds, last) {
+			continue
+		}
+		last = leds
+		fmt.Fprintf(w, "\n%s\n", time.Now().Format("15:04:05.000"))
+		if err := Render(w, leds, rows, cols, wiring, ansi); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package ascii
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+var (
+	black = color.RGBA{A: 255}
+	dim   = color.RGBA{R: 60, A: 255}
+	mid   = color.RGBA{G: 130, B: 20, A: 255}
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+)
+
+func TestRender(t *testing.T) {
+	leds := []color.RGBA{black, dim, mid, white, black, white}
+	for _, tt := range []struct {
+		wiring string
+		want   string
+	}{
+		{"row", " :+\n@ @\n"},
+		{"col", " + \n:@@\n"},
+	} {
+		t.Run(tt.wiring, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Render(&buf, leds, 2, 3, tt.wiring, false); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPanels(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, []color.RGBA{white, black, black, white}, 1, 2, "row", false)
+	if want := "@ \n\n @\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRenderANSI(t *testing.T) {
+	var buf bytes.Buffer
+	Render(&buf, []color.RGBA{mid}, 1, 1, "row", true)
+	if want := "\x1b[38;2;0;130;20m+\x1b[0m\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to write from Run while the test reads it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRunSkipsUnchangedFrames(t *testing.T) {
+	st := state.NewLEDState(2, "#FFFFFF")
+	ctx, cancel := context.WithCancel(context.Background())
+	var out syncBuffer
+	done := make(chan struct{})
+	go func() {
+		Run(ctx, &out, st, 1, 2, "row", 0, false)
+		close(done)
+	}()
+
+	// Three ticks at MinInterval with the frame left alone print it once
+	time.Sleep(3*MinInterval + MinInterval/2)
+	cancel()
+	<-done
+	if n := strings.Count(out.String(), "@@\n"); n != 1 {
+		t.Errorf("frame printed %d times, want 1:\n%s", n, out.String())
+	}
+}
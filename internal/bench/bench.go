@@ -0,0 +1,119 @@
+// Package bench streams DDP frames at a target rate to measure how fast a
+// simulator can receive them.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"wled-simulator/internal/ddp"
+)
+
+// MaxPixelsPerPacket keeps each packet within a standard 1500 byte MTU
+const MaxPixelsPerPacket = 480
+
+// Options configures a benchmark run
+type Options struct {
+	Addr     string        // host:port of the DDP listener
+	FPS      int           // Target frame rate
+	Duration time.Duration // How long to stream for
+	LEDs     int           // Pixels per frame
+}
+
+// Result summarizes a benchmark run
+type Result struct {
+	Frames    int           // Frames sent in full
+	Packets   int           // Packets sent
+	Errors    int           // Packets that failed to send
+	LastError error         // Most recent send error, if any
+	Elapsed   time.Duration // Time spent streaming
+}
+
+// FPS returns the achieved frame rate
+func (r Result) FPS() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Frames) / r.Elapsed.Seconds()
+}
+
+// Run streams frames to opts.Addr at the target rate until the duration has
+// elapsed or ctx is cancelled. Each frame is split into packets of at most
+// MaxPixelsPerPacket pixels, with push set on the last one.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	var result Result
+	if opts.FPS <= 0 {
+		return result, fmt.Errorf("invalid fps %d: must be positive", opts.FPS)
+	}
+	if opts.LEDs <= 0 {
+		return result, fmt.Errorf("invalid LED count %d: must be positive", opts.LEDs)
+	}
+	if opts.Duration <= 0 {
+		return result, fmt.Errorf("invalid duration %v: must be positive", opts.Duration)
+	}
+
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return result, fmt.Errorf("failed to connect to %s: %v", opts.Addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.FPS))
+	defer ticker.Stop()
+
+	payload := make([]byte, opts.LEDs*3)
+	var seq uint8
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			result.Elapsed = time.Since(start)
+			return result, nil
+		case <-ticker.C:
+		}
+
+		fillFrame(payload, result.Frames)
+		sent := true
+		for offset := 0; offset < len(payload); offset += MaxPixelsPerPacket * 3 {
+			end := min(offset+MaxPixelsPerPacket*3, len(payload))
+
+			// Sequence numbers run 1-15; zero would disable duplicate checks
+			seq = seq%15 + 1
+			header := ddp.EncodeHeader(&ddp.DDPHeader{
+				Version:    ddp.DDPVersion,
+				Push:       end == len(payload),
+				Sequence:   seq,
+				DataType:   ddp.DataTypeInfo{Type: ddp.TypeRGB, Size: ddp.Size8Bit},
+				DeviceID:   ddp.DeviceIDDefault,
+				DataOffset: uint32(offset),
+				DataLength: uint16(end - offset),
+			})
+
+			result.Packets++
+			if _, err := conn.Write(append(header, payload[offset:end]...)); err != nil {
+				result.Errors++
+				result.LastError = err
+				sent = false
+			}
+		}
+		if sent {
+			result.Frames++
+		}
+	}
+}
+
+// fillFrame writes a solid colour that shifts with each frame, so that
+// successive frames are visibly different
+func fillFrame(payload []byte, frame int) {
+	r, g := uint8(frame), uint8(255-frame)
+	for i := 0; i+2 < len(payload); i += 3 {
+		payload[i] = r
+		payload[i+1] = g
+		payload[i+2] = 128
+	}
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/state"
+)
+
+func TestRunDeliversFrames(t *testing.T) {
+	const testPort = 4063
+	ledState := state.NewLEDState(600, "#000000")
+	srv := ddp.NewServer(testPort, ledState)
+	srv.SetHost("127.0.0.1")
+	if err := srv.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer srv.Stop()
+
+	// 600 LEDs need two packets per frame
+	result, err := Run(context.Background(), Options{
+		Addr:     "127.0.0.1:4063",
+		FPS:      50,
+		Duration: 200 * time.Millisecond,
+		LEDs:     600,
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Frames == 0 {
+		t.Fatal("expected frames to be sent")
+	}
+	if result.Errors != 0 {
+		t.Errorf("expected no send errors, got %d: %v", result.Errors, result.LastError)
+	}
+	if result.Packets != 2*result.Frames {
+		t.Errorf("sent %d packets for %d frames, want 2 per frame", result.Packets, result.Frames)
+	}
+	if result.FPS() <= 0 {
+		t.Errorf("expected a positive frame rate, got %v", result.FPS())
+	}
+
+	// Give the server a moment to process the last packets
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := srv.LastFrame(); !ok {
+		t.Fatal("expected the server to have committed a frame")
+	}
+	leds := ledState.LEDs()
+	if leds[599].B != 128 {
+		t.Errorf("last LED = %v, want blue channel 128 from the bench pattern", leds[599])
+	}
+}
+
+func TestRunValidatesOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"zero fps", Options{Addr: "127.0.0.1:4048", FPS: 0, Duration: time.Second, LEDs: 1}},
+		{"zero LEDs", Options{Addr: "127.0.0.1:4048", FPS: 1, Duration: time.Second, LEDs: 0}},
+		{"zero duration", Options{Addr: "127.0.0.1:4048", FPS: 1, Duration: 0, LEDs: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Run(context.Background(), tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ddp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// ChecksumSize is the length of the trailing checksum added by
+// AppendChecksum. The checksum is not part of the DDP spec; sender and
+// simulator must both opt in.
+const ChecksumSize = 4
+
+// AppendChecksum returns payload followed by its big-endian CRC-32 (IEEE),
+// as expected by a server with SetChecksum enabled
+func AppendChecksum(payload []byte) []byte {
+	out := make([]byte, len(payload), len(payload)+ChecksumSize)
+	copy(out, payload)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(payload))
+}
+
+// SetChecksum requires every packet's data to end in a CRC-32 of the pixel
+// data before it, as written by AppendChecksum. Packets that are too short or
+// whose checksum does not match are dropped and counted. Must be called
+// before Start.
+func (s *Server) SetChecksum(on bool) {
+	s.checksum = on
+}
+
+// ChecksumFailures returns how many packets were dropped for a missing or
+// wrong checksum
+func (s *Server) ChecksumFailures() int {
+	s.frameMu.RLock()
+	defer s.frameMu.RUnlock()
+	return s.badChecksums
+}
+
+// verifyChecksum checks and strips the trailing checksum from payload,
+// counting the packet as failed if it does not match
+func (s *Server) verifyChecksum(payload []byte) ([]byte, error) {
+	err := checkTrailer(payload)
+	if err == nil {
+		return payload[:len(payload)-ChecksumSize], nil
+	}
+	s.frameMu.Lock()
+	s.badChecksums++
+	s.frameMu.Unlock()
+	return nil, err
+}
+
+// checkTrailer reports whether payload ends in the CRC-32 of the bytes before it
+func checkTrailer(payload []byte) error {
+	if len(payload) < ChecksumSize {
+		return fmt.Errorf("payload of %d bytes is too short for a %d byte checksum", len(payload), ChecksumSize)
+	}
+	data := payload[:len(payload)-ChecksumSize]
+	want := binary.BigEndian.Uint32(payload[len(data):])
+	if got := crc32.ChecksumIEEE(data); got != want {
+		return fmt.Errorf("checksum mismatch: got %08x, packet says %08x", got, want)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package ddp
+
+import (
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestChecksum(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetChecksum(true)
+
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, AppendChecksum([]byte{10, 20, 30, 40, 50, 60})))
+	want := []color.RGBA{{10, 20, 30, 255}, {40, 50, 60, 255}}
+	if got := ledState.LEDs(); got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("LEDs = %v, want %v from the good packet", got, want)
+	}
+
+	// Flip a pixel byte after the checksum was computed
+	corrupt := AppendChecksum([]byte{99, 99, 99, 0, 0, 0})
+	corrupt[0] = 98
+	data := buildPacket(FlagPush, 2, 0, corrupt)
+	header, err := ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if err := s.processPacket(header, data, nil); err == nil {
+		t.Error("expected a checksum error for the corrupted packet")
+	}
+	if got := ledState.LEDs(); got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("LEDs = %v, want the corrupted packet dropped", got)
+	}
+
+	// Too short to hold a checksum at all
+	data = buildPacket(FlagPush, 3, 0, []byte{1, 2, 3})
+	header, _ = ParseHeader(data)
+	if err := s.processPacket(header, data, nil); err == nil {
+		t.Error("expected an error for a packet without a checksum")
+	}
+
+	if got := s.ChecksumFailures(); got != 2 {
+		t.Errorf("ChecksumFailures = %d, want 2", got)
+	}
+}
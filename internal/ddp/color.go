@@ -0,0 +1,81 @@
+package ddp
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// OverflowMode controls how channel arithmetic that exceeds 255 is resolved
+type OverflowMode int
+
+const (
+	OverflowClamp OverflowMode = iota // Saturate at 255
+	OverflowWrap                      // Wrap around modulo 256
+)
+
+// ParseOverflowMode converts "clamp" or "wrap" to an OverflowMode
+func ParseOverflowMode(s string) (OverflowMode, error) {
+	switch s {
+	case "clamp":
+		return OverflowClamp, nil
+	case "wrap":
+		return OverflowWrap, nil
+	}
+	return OverflowClamp, fmt.Errorf("invalid overflow mode '%s'. Must be 'clamp' or 'wrap'", s)
+}
+
+func (m OverflowMode) String() string {
+	if m == OverflowWrap {
+		return "wrap"
+	}
+	return "clamp"
+}
+
+// add sums two channel values according to the overflow mode
+func (m OverflowMode) add(a, b uint8) uint8 {
+	sum := int(a) + int(b)
+	if sum > 255 && m == OverflowClamp {
+		return 255
+	}
+	return uint8(sum)
+}
+
+// ColorOrder names the channel carried by each of the first three bytes of a
+// pixel, e.g. "GRB" for strips that expect green first
+type ColorOrder string
+
+// ColorOrderRGB is the DDP standard byte order
+const ColorOrderRGB ColorOrder = "RGB"
+
+// ParseColorOrder accepts any ordering of the letters R, G and B, in any case
+func ParseColorOrder(s string) (ColorOrder, error) {
+	order := strings.ToUpper(s)
+	if len(order) != 3 || !strings.ContainsRune(order, 'R') || !strings.ContainsRune(order, 'G') || !strings.ContainsRune(order, 'B') {
+		return ColorOrderRGB, fmt.Errorf("invalid color order '%s'. Must be an ordering of R, G and B such as 'RGB' or 'GRB'", s)
+	}
+	return ColorOrder(order), nil
+}
+
+// decodePixel converts one pixel's bytes, arranged in order, to a color.
+// RGBW pixels have their white channel added to each of R, G and B.
+func decodePixel(p []byte, overflow OverflowMode, order ColorOrder) color.RGBA {
+	c := color.RGBA{A: 255}
+	for i := 0; i < 3; i++ {
+		switch order[i] {
+		case 'R':
+			c.R = p[i]
+		case 'G':
+			c.G = p[i]
+		case 'B':
+			c.B = p[i]
+		}
+	}
+	if len(p) >= 4 {
+		w := p[3]
+		c.R = overflow.add(c.R, w)
+		c.G = overflow.add(c.G, w)
+		c.B = overflow.add(c.B, w)
+	}
+	return c
+}
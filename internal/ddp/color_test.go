@@ -0,0 +1,166 @@
+package ddp
+
+import (
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestParseOverflowMode(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OverflowMode
+		wantErr bool
+	}{
+		{"clamp", OverflowClamp, false},
+		{"wrap", OverflowWrap, false},
+		{"saturate", OverflowClamp, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseOverflowMode(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOverflowMode(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseOverflowMode(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseColorOrder(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ColorOrder
+		wantErr bool
+	}{
+		{"RGB", "RGB", false},
+		{"grb", "GRB", false},
+		{"BGR", "BGR", false},
+		{"RRG", ColorOrderRGB, true},
+		{"RGBW", ColorOrderRGB, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseColorOrder(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseColorOrder(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseColorOrder(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+
+	if got := decodePixel([]byte{1, 2, 3}, OverflowClamp, "BRG"); got != (color.RGBA{2, 3, 1, 255}) {
+		t.Errorf("decodePixel BRG = %v, want {2 3 1 255}", got)
+	}
+}
+
+func TestRGBWWhiteOverflow(t *testing.T) {
+	tests := []struct {
+		name string
+		mode OverflowMode
+		want color.RGBA
+	}{
+		// R=200 + W=100 = 300: clamps to 255 or wraps to 44
+		{"clamp", OverflowClamp, color.RGBA{R: 255, G: 110, B: 100, A: 255}},
+		{"wrap", OverflowWrap, color.RGBA{R: 44, G: 110, B: 100, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ledState := state.NewLEDState(1, "#000000")
+			s := NewServer(4048, ledState)
+			if err := s.SetBytesPerPixel(4); err != nil {
+				t.Fatalf("SetBytesPerPixel failed: %v", err)
+			}
+			s.SetOverflow(tt.mode)
+
+			feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{200, 10, 0, 100}))
+
+			if got := ledState.LEDs()[0]; got != tt.want {
+				t.Errorf("LED = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRGBWSeparateWhite(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+	if err := s.SetBytesPerPixel(4); err != nil {
+		t.Fatalf("SetBytesPerPixel failed: %v", err)
+	}
+	s.SetSeparateWhite(true)
+
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{200, 10, 0, 100, 1, 2, 3, 0}))
+
+	if got, want := ledState.LEDs()[0], (color.RGBA{R: 200, G: 10, B: 0, A: 255}); got != want {
+		t.Errorf("LED 0 = %v, want %v with white not added", got, want)
+	}
+	if got := ledState.White(); got[0] != 100 || got[1] != 0 {
+		t.Errorf("White = %v, want [100 0]", got)
+	}
+}
+
+func TestRGBWStateDecodesFourBytes(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	ledState.SetRGBW(true)
+	s := NewServer(4048, ledState) // Left at 3 bytes per pixel
+
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{200, 10, 0, 100, 1, 2, 3, 40}))
+
+	leds, white := ledState.LEDs(), ledState.White()
+	if want := (color.RGBA{R: 200, G: 10, B: 0, A: 255}); leds[0] != want {
+		t.Errorf("LED 0 = %v, want %v", leds[0], want)
+	}
+	if want := (color.RGBA{R: 1, G: 2, B: 3, A: 255}); leds[1] != want {
+		t.Errorf("LED 1 = %v, want %v", leds[1], want)
+	}
+	if white[0] != 100 || white[1] != 40 {
+		t.Errorf("White = %v, want [100 40]", white)
+	}
+
+	if got := s.DecodeConfig().BytesPerPixel; got != 4 {
+		t.Errorf("DecodeConfig bpp = %d, want 4", got)
+	}
+	if err := s.SetDecodeConfig(DecodeConfig{BytesPerPixel: 3, ColorOrder: "RGB"}); err == nil {
+		t.Error("expected error setting 3 bytes per pixel for RGBW LEDs")
+	}
+}
+
+func TestRGBWTypedPacket(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	ledState.SetRGBW(true)
+	s := NewServer(4048, ledState)
+
+	packet := buildPacket(FlagPush, 1, 0, []byte{200, 10, 0, 100, 1, 2, 3, 40})
+	packet[2] = 0x1B // RGBW, 8 bits per element
+	feedPacket(t, s, packet)
+
+	leds, white := ledState.LEDs(), ledState.White()
+	if want := (color.RGBA{R: 200, G: 10, B: 0, A: 255}); leds[0] != want {
+		t.Errorf("LED 0 = %v, want %v", leds[0], want)
+	}
+	if want := (color.RGBA{R: 1, G: 2, B: 3, A: 255}); leds[1] != want {
+		t.Errorf("LED 1 = %v, want %v", leds[1], want)
+	}
+	if white[0] != 100 || white[1] != 40 {
+		t.Errorf("White = %v, want [100 40]", white)
+	}
+}
+
+func TestSetBytesPerPixelRejectsInvalid(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(1, "#000000"))
+	if err := s.SetBytesPerPixel(5); err == nil {
+		t.Error("expected error for 5 bytes per pixel")
+	}
+	if s.bytesPerPixel != 3 {
+		t.Errorf("bytesPerPixel = %d, want unchanged 3", s.bytesPerPixel)
+	}
+}
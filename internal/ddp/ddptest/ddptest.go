@@ -0,0 +1,56 @@
+// Package ddptest builds canonical DDP packets for tests that drive the
+// simulator, so they don't have to assemble headers by hand.
+package ddptest
+
+import (
+	"encoding/binary"
+	"image/color"
+
+	"wled-simulator/internal/ddp"
+)
+
+// RGBPacket returns a version 1, 8-bit RGB packet for the default device
+// carrying colors, starting at the byte offset into the frame. push sets the
+// push flag that commits the frame.
+func RGBPacket(seq uint8, offset uint32, colors []color.RGBA, push bool) []byte {
+	payload := make([]byte, 0, len(colors)*3)
+	for _, c := range colors {
+		payload = append(payload, c.R, c.G, c.B)
+	}
+	header := ddp.EncodeHeader(&ddp.DDPHeader{
+		Version:    ddp.DDPVersion,
+		Push:       push,
+		Sequence:   seq,
+		DataType:   ddp.DataTypeInfo{Type: ddp.TypeRGB, Size: ddp.Size8Bit},
+		DeviceID:   ddp.DeviceIDDefault,
+		DataOffset: offset,
+		DataLength: uint16(len(payload)),
+	})
+	return append(header, payload...)
+}
+
+// RGBFrame returns a pushed RGB packet, the usual way to send a whole frame
+// in one packet
+func RGBFrame(seq uint8, offset uint32, colors []color.RGBA) []byte {
+	return RGBPacket(seq, offset, colors, true)
+}
+
+// QueryPacket returns a version 1 query packet with no payload, as senders
+// use to discover a device
+func QueryPacket() []byte {
+	return ddp.EncodeHeader(&ddp.DDPHeader{
+		Version:  ddp.DDPVersion,
+		Query:    true,
+		DeviceID: ddp.DeviceIDDefault,
+	})
+}
+
+// Stream length-prefixes packets in the format read by Server.ServeReader
+func Stream(packets ...[]byte) []byte {
+	var out []byte
+	for _, p := range packets {
+		out = binary.BigEndian.AppendUint16(out, uint16(len(p)))
+		out = append(out, p...)
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package ddptest
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/ddp"
+	"wled-simulator/internal/state"
+)
+
+func TestRGBFrameParses(t *testing.T) {
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}}
+	data := RGBFrame(5, 6, colors)
+
+	header, err := ddp.ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if err := ddp.ValidateHeader(header, nil); err != nil {
+		t.Fatalf("ValidateHeader failed: %v", err)
+	}
+	if !header.Push || header.Sequence != 5 || header.DataOffset != 6 || header.DataLength != 6 {
+		t.Errorf("unexpected header: %+v", header)
+	}
+	if header.DataType.Type != ddp.TypeRGB || header.DataType.BitsPerElement != 8 {
+		t.Errorf("unexpected data type: %+v", header.DataType)
+	}
+	if payload := data[ddp.MinHeaderSize:]; !bytes.Equal(payload, []byte{255, 0, 0, 0, 255, 0}) {
+		t.Errorf("payload = %v", payload)
+	}
+
+	if header, _ := ddp.ParseHeader(RGBPacket(1, 0, colors, false)); header.Push {
+		t.Error("expected RGBPacket without push to leave the flag clear")
+	}
+}
+
+func TestQueryPacketParses(t *testing.T) {
+	header, err := ddp.ParseHeader(QueryPacket())
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if !header.Query || header.Push || header.DataLength != 0 {
+		t.Errorf("unexpected header: %+v", header)
+	}
+}
+
+func TestStreamServes(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := ddp.NewServer(4048, ledState)
+
+	blue := color.RGBA{0, 0, 255, 255}
+	stream := Stream(RGBPacket(1, 0, []color.RGBA{blue}, false), RGBFrame(2, 3, []color.RGBA{blue}))
+	if err := s.ServeReader(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+	for i, c := range ledState.LEDs() {
+		if c != blue {
+			t.Errorf("LED %d = %v, want %v", i, c, blue)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ddp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"wled-simulator/internal/state"
+)
+
+// ParseDeviceSegmentMap reads a comma-separated list of device=segment pairs,
+// such as "2=1,3=2", routing each DDP device ID to the segment with that id.
+// Device IDs 2-245 may be mapped; the rest are reserved by the protocol.
+func ParseDeviceSegmentMap(s string) (map[DeviceID]int, error) {
+	m := make(map[DeviceID]int)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		dev, seg, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid device segment mapping '%s'. Must be device=segment such as '2=1'", pair)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(dev))
+		if err != nil || id <= int(DeviceIDDefault) || id >= int(DeviceIDJSONControl) {
+			return nil, fmt.Errorf("invalid device ID '%s'. Must be %d-%d", dev, DeviceIDDefault+1, DeviceIDJSONControl-1)
+		}
+		segID, err := strconv.Atoi(strings.TrimSpace(seg))
+		if err != nil || segID < 0 {
+			return nil, fmt.Errorf("invalid segment id '%s' for device %d", seg, id)
+		}
+		if _, dup := m[DeviceID(id)]; dup {
+			return nil, fmt.Errorf("device ID %d is mapped more than once", id)
+		}
+		m[DeviceID(id)] = segID
+	}
+	return m, nil
+}
+
+// SetDeviceSegments routes packets for each mapped device ID into the segment
+// with the mapped id, offsets counting from the segment start. Packets for the
+// default device and broadcast still address the whole strip.
+func (s *Server) SetDeviceSegments(m map[DeviceID]int) {
+	routes := make(map[DeviceID]int, len(m))
+	for dev, seg := range m {
+		routes[dev] = seg
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.devSegments = routes
+}
+
+// deviceRange returns the LED range addressed by packets for dev, given the
+// current segments and n LEDs
+func (s *Server) deviceRange(dev DeviceID, segments []state.Segment, n int) (lo, hi int, err error) {
+	s.cfgMu.RLock()
+	segID, mapped := s.devSegments[dev]
+	s.cfgMu.RUnlock()
+	if !mapped {
+		return 0, n, nil
+	}
+	for _, seg := range segments {
+		if seg.ID == segID {
+			return min(seg.Start, n), min(seg.Stop, n), nil
+		}
+	}
+	return 0, 0, fmt.Errorf("device %d is mapped to segment %d, which does not exist", dev, segID)
+}
+
+// mappedDevice reports whether dev has been routed to a segment
+func (s *Server) mappedDevice(dev DeviceID) bool {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	_, ok := s.devSegments[dev]
+	return ok
+}
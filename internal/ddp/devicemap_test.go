@@ -0,0 +1,78 @@
+package ddp
+
+import (
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+// devicePacket is buildPacket addressed to device dev
+func devicePacket(dev DeviceID, seq uint8, offset uint32, payload []byte) []byte {
+	data := buildPacket(FlagPush, seq, offset, payload)
+	data[3] = byte(dev)
+	return data
+}
+
+func TestDeviceSegmentRouting(t *testing.T) {
+	ledState := state.NewLEDState(6, "#000000")
+	ledState.SetSegment(0, state.Segment{ID: 0, Start: 0, Stop: 3, On: true})
+	ledState.SetSegment(1, state.Segment{ID: 1, Start: 3, Stop: 6, On: true})
+	s := NewServer(4048, ledState)
+	s.SetDeviceSegments(map[DeviceID]int{2: 1})
+
+	red, green, black := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 0, 255}
+
+	// Device 2 writes from the start of the second segment and stops at its end
+	payload := []byte{255, 0, 0, 255, 0, 0, 255, 0, 0, 255, 0, 0}
+	s.handlePacket(devicePacket(2, 1, 0, payload), nil)
+	for i, want := range []color.RGBA{black, black, black, red, red, red} {
+		if got := ledState.LEDs()[i]; got != want {
+			t.Errorf("after device 2: LED %d = %v, want %v", i, got, want)
+		}
+	}
+
+	// Broadcast still addresses the whole strip
+	s.handlePacket(devicePacket(DeviceIDAllDevices, 2, 0, []byte{0, 255, 0, 0, 255, 0, 0, 255, 0, 0, 255, 0}), nil)
+	for i, want := range []color.RGBA{green, green, green, green, red, red} {
+		if got := ledState.LEDs()[i]; got != want {
+			t.Errorf("after broadcast: LED %d = %v, want %v", i, got, want)
+		}
+	}
+
+	// Unmapped devices are still rejected
+	s.handlePacket(devicePacket(3, 3, 0, []byte{255, 0, 0}), nil)
+	if got := ledState.LEDs()[0]; got != green {
+		t.Errorf("unmapped device 3 wrote LED 0 = %v", got)
+	}
+}
+
+func TestDeviceSegmentMissingSegment(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(4, "#000000"))
+	s.SetDeviceSegments(map[DeviceID]int{2: 5})
+
+	data := devicePacket(2, 1, 0, []byte{255, 0, 0})
+	header, err := ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if err := s.processPacket(header, data, nil); err == nil {
+		t.Error("expected an error for a device mapped to a missing segment")
+	}
+}
+
+func TestParseDeviceSegmentMap(t *testing.T) {
+	m, err := ParseDeviceSegmentMap(" 2=1, 3=0 ,")
+	if err != nil {
+		t.Fatalf("ParseDeviceSegmentMap failed: %v", err)
+	}
+	if len(m) != 2 || m[2] != 1 || m[3] != 0 {
+		t.Errorf("got %v, want map[2:1 3:0]", m)
+	}
+
+	for _, bad := range []string{"2", "1=0", "255=0", "246=0", "x=1", "2=-1", "2=1,2=3"} {
+		if _, err := ParseDeviceSegmentMap(bad); err == nil {
+			t.Errorf("ParseDeviceSegmentMap(%q) succeeded, want an error", bad)
+		}
+	}
+}
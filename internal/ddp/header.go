@@ -119,6 +119,12 @@ func parseDataType(dataTypeByte uint8) DataTypeInfo {
 
 // ParseHeader parses and validates a DDP packet header
 func ParseHeader(data []byte) (*DDPHeader, error) {
+	return parseHeader(data, false)
+}
+
+// parseHeader parses a DDP packet header. When lenient, version 0, as sent
+// by some older senders, is read as version 1.
+func parseHeader(data []byte, lenient bool) (*DDPHeader, error) {
 	if len(data) < MinHeaderSize {
 		return nil, fmt.Errorf("packet too short: got %d bytes, need at least %d", len(data), MinHeaderSize)
 	}
@@ -135,6 +141,9 @@ func ParseHeader(data []byte) (*DDPHeader, error) {
 	header.Push = (flags & FlagPush) != 0
 
 	// Validate version
+	if lenient && header.Version == 0 {
+		header.Version = DDPVersion
+	}
 	if header.Version != DDPVersion {
 		return nil, fmt.Errorf("unsupported DDP version: got %d, expected %d", header.Version, DDPVersion)
 	}
@@ -180,10 +189,69 @@ func ParseHeader(data []byte) (*DDPHeader, error) {
 	return header, nil
 }
 
+// EncodeHeader serializes a header into its wire format, the inverse of
+// ParseHeader. The timecode is included only if HasTimecode is set.
+func EncodeHeader(header *DDPHeader) []byte {
+	size := MinHeaderSize
+	if header.HasTimecode {
+		size = MaxHeaderSize
+	}
+	data := make([]byte, size)
+
+	// Byte 0 (flags)
+	flags := (header.Version << FlagVersionShift) & FlagVersionMask
+	if header.HasTimecode {
+		flags |= FlagTimecode
+	}
+	if header.Storage {
+		flags |= FlagStorage
+	}
+	if header.Reply {
+		flags |= FlagReply
+	}
+	if header.Query {
+		flags |= FlagQuery
+	}
+	if header.Push {
+		flags |= FlagPush
+	}
+	data[0] = flags
+
+	// Byte 1 (sequence), lower 4 bits only
+	data[1] = header.Sequence & 0x0F
+
+	// Byte 2 (data type)
+	dataType := (header.DataType.Type << 3) & DataTypeTypeMask
+	dataType |= header.DataType.Size & DataTypeSizeMask
+	if header.DataType.IsCustom {
+		dataType |= DataTypeCustomMask
+	}
+	data[2] = dataType
+
+	// Byte 3 (device ID)
+	data[3] = uint8(header.DeviceID)
+
+	// Bytes 4-9 (data offset and length, big-endian)
+	binary.BigEndian.PutUint32(data[4:8], header.DataOffset)
+	binary.BigEndian.PutUint16(data[8:10], header.DataLength)
+
+	if header.HasTimecode {
+		binary.BigEndian.PutUint32(data[10:14], header.Timecode)
+	}
+
+	return data
+}
+
 // ValidateHeader performs additional validation on the parsed header
 func ValidateHeader(header *DDPHeader, lastSequence *uint8) error {
+	return validateHeader(header, lastSequence, false)
+}
+
+// validateHeader is ValidateHeader, also accepting the header's device ID
+// when mapped is set because it has been routed to a segment
+func validateHeader(header *DDPHeader, lastSequence *uint8, mapped bool) error {
 	// Check device ID
-	if header.DeviceID != DeviceIDDefault && header.DeviceID != DeviceIDAllDevices {
+	if !mapped && header.DeviceID != DeviceIDDefault && header.DeviceID != DeviceIDAllDevices {
 		return fmt.Errorf("unsupported device ID: %d (expected %d or %d)",
 			header.DeviceID, DeviceIDDefault, DeviceIDAllDevices)
 	}
@@ -193,31 +261,48 @@ func ValidateHeader(header *DDPHeader, lastSequence *uint8) error {
 		return fmt.Errorf("custom data types not supported (C bit set)")
 	}
 
-	// Check data type - we only support RGB and undefined
-	if header.DataType.Type != TypeRGB && header.DataType.Type != TypeUndefined {
+	// Check data type - we only support RGB, RGBW, grayscale and undefined
+	switch header.DataType.Type {
+	case TypeRGB, TypeRGBW, TypeGrayscale, TypeUndefined:
+	default:
 		typeName := "unknown"
-		switch header.DataType.Type {
-		case TypeHSL:
+		if header.DataType.Type == TypeHSL {
 			typeName = "HSL"
-		case TypeRGBW:
-			typeName = "RGBW"
-		case TypeGrayscale:
-			typeName = "Grayscale"
 		}
-		return fmt.Errorf("unsupported data type: %s (%d), only RGB (%d) and undefined (%d) supported",
-			typeName, header.DataType.Type, TypeRGB, TypeUndefined)
+		return fmt.Errorf("unsupported data type: %s (%d), only RGB (%d), RGBW (%d), grayscale (%d) and undefined (%d) supported",
+			typeName, header.DataType.Type, TypeRGB, TypeRGBW, TypeGrayscale, TypeUndefined)
 	}
 
-	// For RGB data, check that we have 8 bits per element
+	// RGB and grayscale elements are 8 or 16 bits, the latter scaled down
 	if header.DataType.Type == TypeRGB {
-		if header.DataType.Size != Size8Bit {
-			return fmt.Errorf("unsupported RGB size: %d bits per element (expected 8)",
+		if header.DataType.Size != Size8Bit && header.DataType.Size != Size16Bit {
+			return fmt.Errorf("unsupported RGB size: %d bits per element (expected 8 or 16)",
+				header.DataType.BitsPerElement)
+		}
+	}
+	// RGBW pixels are always read as four 8-bit elements
+	if header.DataType.Type == TypeRGBW && header.DataType.Size != Size8Bit {
+		return fmt.Errorf("unsupported RGBW size: %d bits per element (expected 8)",
+			header.DataType.BitsPerElement)
+	}
+	if header.DataType.Type == TypeGrayscale {
+		if header.DataType.Size != Size8Bit && header.DataType.Size != Size16Bit {
+			return fmt.Errorf("unsupported grayscale size: %d bits per element (expected 8 or 16)",
+				header.DataType.BitsPerElement)
+		}
+	}
+
+	// Undefined data is decoded as 8-bit RGB, so only an undefined or 8-bit size makes sense
+	if header.DataType.Type == TypeUndefined {
+		if header.DataType.Size != SizeUndefined && header.DataType.Size != Size8Bit {
+			return fmt.Errorf("unsupported size for undefined data type: %d bits per element (expected undefined or 8)",
 				header.DataType.BitsPerElement)
 		}
 	}
 
-	// Check sequence number for duplicates (if not zero)
-	if header.Sequence != 0 && lastSequence != nil {
+	// Check sequence number for duplicates (if not zero). Replies echo the
+	// sequence of the request they answer, so they are not tracked.
+	if header.Sequence != 0 && lastSequence != nil && !header.Reply {
 		if header.Sequence == *lastSequence {
 			return fmt.Errorf("duplicate sequence number: %d", header.Sequence)
 		}
@@ -375,7 +375,7 @@ func TestValidateHeader(t *testing.T) {
 			expectedError: "unsupported data type: HSL",
 		},
 		{
-			name: "RGBW data type not supported",
+			name: "valid RGBW 8-bit header",
 			header: &DDPHeader{
 				Version:  1,
 				DeviceID: DeviceIDDefault,
@@ -386,21 +386,34 @@ func TestValidateHeader(t *testing.T) {
 					BitsPerElement: 8,
 				},
 			},
-			expectedError: "unsupported data type: RGBW",
 		},
 		{
-			name: "Grayscale data type not supported",
+			name: "RGBW with wrong bit size",
+			header: &DDPHeader{
+				Version:  1,
+				DeviceID: DeviceIDDefault,
+				DataType: DataTypeInfo{
+					IsCustom:       false,
+					Type:           TypeRGBW,
+					Size:           Size16Bit,
+					BitsPerElement: 16,
+				},
+			},
+			expectedError: "unsupported RGBW size: 16 bits per element",
+		},
+		{
+			name: "Grayscale with wrong bit size",
 			header: &DDPHeader{
 				Version:  1,
 				DeviceID: DeviceIDDefault,
 				DataType: DataTypeInfo{
 					IsCustom:       false,
 					Type:           TypeGrayscale,
-					Size:           Size8Bit,
-					BitsPerElement: 8,
+					Size:           Size4Bit,
+					BitsPerElement: 4,
 				},
 			},
-			expectedError: "unsupported data type: Grayscale",
+			expectedError: "unsupported grayscale size: 4 bits per element",
 		},
 		{
 			name: "RGB with wrong bit size",
@@ -410,11 +423,11 @@ func TestValidateHeader(t *testing.T) {
 				DataType: DataTypeInfo{
 					IsCustom:       false,
 					Type:           TypeRGB,
-					Size:           Size16Bit,
-					BitsPerElement: 16,
+					Size:           Size24Bit,
+					BitsPerElement: 24,
 				},
 			},
-			expectedError: "unsupported RGB size: 16 bits per element",
+			expectedError: "unsupported RGB size: 24 bits per element",
 		},
 		{
 			name: "duplicate sequence number",
@@ -432,6 +445,19 @@ func TestValidateHeader(t *testing.T) {
 			lastSequence:  5,
 			expectedError: "duplicate sequence number",
 		},
+		{
+			name: "Undefined type with 16-bit size",
+			header: &DDPHeader{
+				DeviceID: DeviceIDDefault,
+				DataType: DataTypeInfo{
+					IsCustom:       false,
+					Type:           TypeUndefined,
+					Size:           Size16Bit,
+					BitsPerElement: 16,
+				},
+			},
+			expectedError: "unsupported size for undefined data type",
+		},
 	}
 
 	for _, tt := range tests {
@@ -473,3 +499,55 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestEncodeHeaderRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		header DDPHeader
+	}{
+		{
+			name: "RGB push",
+			header: DDPHeader{
+				Version:    DDPVersion,
+				Push:       true,
+				Sequence:   7,
+				DataType:   DataTypeInfo{Type: TypeRGB, Size: Size8Bit, BitsPerElement: 8},
+				DeviceID:   DeviceIDDefault,
+				DataOffset: 1440,
+				DataLength: 6,
+			},
+		},
+		{
+			name: "all flags with timecode",
+			header: DDPHeader{
+				Version:     DDPVersion,
+				HasTimecode: true,
+				Storage:     true,
+				Reply:       true,
+				Query:       true,
+				Push:        true,
+				Sequence:    15,
+				DataType:    DataTypeInfo{IsCustom: true, Type: TypeGrayscale, Size: Size16Bit, BitsPerElement: 16},
+				DeviceID:    DeviceIDAllDevices,
+				DataOffset:  0xDEADBEEF,
+				DataLength:  2,
+				Timecode:    0x01020304,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := EncodeHeader(&tt.header)
+			data = append(data, make([]byte, tt.header.DataLength)...)
+
+			got, err := ParseHeader(data)
+			if err != nil {
+				t.Fatalf("ParseHeader failed: %v", err)
+			}
+			if *got != tt.header {
+				t.Errorf("round trip = %+v, want %+v", *got, tt.header)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package ddp
+
+// MaxHistory bounds the number of frames SetHistory will keep
+const MaxHistory = 1024
+
+// frameHistory is a fixed-size ring of the most recently committed frames
+type frameHistory struct {
+	frames []Frame
+	next   int // Slot the next frame is written to
+	count  int
+}
+
+func newFrameHistory(size int) *frameHistory {
+	return &frameHistory{frames: make([]Frame, size)}
+}
+
+func (h *frameHistory) add(f Frame) {
+	h.frames[h.next] = f
+	h.next = (h.next + 1) % len(h.frames)
+	h.count = min(h.count+1, len(h.frames))
+}
+
+// last returns up to n frames, oldest first
+func (h *frameHistory) last(n int) []Frame {
+	n = min(n, h.count)
+	out := make([]Frame, n)
+	start := h.next - n + len(h.frames)
+	for i := range out {
+		out[i] = h.frames[(start+i)%len(h.frames)]
+	}
+	return out
+}
+
+// SetHistory keeps the last size committed frames for History, up to
+// MaxHistory. Zero disables the history. Existing frames are discarded.
+func (s *Server) SetHistory(size int) {
+	size = min(size, MaxHistory)
+	s.frameMu.Lock()
+	defer s.frameMu.Unlock()
+	if size <= 0 {
+		s.history = nil
+		return
+	}
+	s.history = newFrameHistory(size)
+}
+
+// History returns up to n of the most recently committed frames, oldest
+// first. A negative n returns every stored frame.
+func (s *Server) History(n int) []Frame {
+	s.frameMu.RLock()
+	defer s.frameMu.RUnlock()
+	if s.history == nil {
+		return nil
+	}
+	if n < 0 {
+		n = s.history.count
+	}
+	return s.history.last(n)
+}
@@ -0,0 +1,36 @@
+package ddp
+
+import (
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestHistoryKeepsLastFrames(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(1, "#000000"))
+	if got := s.History(-1); got != nil {
+		t.Fatalf("expected no history by default, got %v", got)
+	}
+
+	s.SetHistory(2)
+	for seq := uint8(1); seq <= 3; seq++ {
+		feedPacket(t, s, buildPacket(FlagPush, seq, 0, []byte{seq, 0, 0}))
+	}
+
+	// The oldest frame has been overwritten
+	frames := s.History(-1)
+	if len(frames) != 2 || frames[0].Sequence != 2 || frames[1].Sequence != 3 {
+		t.Fatalf("history = %+v, want sequences 2 and 3", frames)
+	}
+	if frames[1].LEDs[0].R != 3 {
+		t.Errorf("newest frame LED 0 = %v, want red 3", frames[1].LEDs[0])
+	}
+	if frames := s.History(1); len(frames) != 1 || frames[0].Sequence != 3 {
+		t.Errorf("History(1) = %+v, want only sequence 3", frames)
+	}
+
+	s.SetHistory(MaxHistory + 1)
+	if got := len(s.history.frames); got != MaxHistory {
+		t.Errorf("history size = %d, want capped at %d", got, MaxHistory)
+	}
+}
@@ -0,0 +1,92 @@
+package ddp
+
+import (
+	"context"
+	"image/color"
+	"sync"
+	"time"
+)
+
+// DefaultJitterCapacity is the number of frames the jitter buffer holds before dropping
+const DefaultJitterCapacity = 8
+
+// jitterBuffer queues committed frames and releases them at a steady interval,
+// trading latency for smooth playback when frames arrive in bursts
+type jitterBuffer struct {
+	mu       sync.Mutex
+	frames   [][]color.RGBA
+	capacity int
+	interval time.Duration
+	dropped  int
+}
+
+func newJitterBuffer(interval time.Duration, capacity int) *jitterBuffer {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &jitterBuffer{
+		frames:   make([][]color.RGBA, 0, capacity),
+		capacity: capacity,
+		interval: interval,
+	}
+}
+
+// push queues a frame, dropping the oldest queued frame if the buffer is full.
+// It returns false if a frame had to be dropped.
+func (j *jitterBuffer) push(frame []color.RGBA) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	ok := true
+	if len(j.frames) >= j.capacity {
+		j.frames = j.frames[1:]
+		j.dropped++
+		ok = false
+	}
+	j.frames = append(j.frames, frame)
+	return ok
+}
+
+// pop removes and returns the oldest queued frame
+func (j *jitterBuffer) pop() ([]color.RGBA, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if len(j.frames) == 0 {
+		return nil, false
+	}
+	frame := j.frames[0]
+	j.frames = j.frames[1:]
+	return frame, true
+}
+
+// Len returns the number of queued frames
+func (j *jitterBuffer) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.frames)
+}
+
+// Dropped returns the number of frames dropped due to overflow
+func (j *jitterBuffer) Dropped() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.dropped
+}
+
+// run releases one queued frame per interval to apply until ctx is cancelled
+func (j *jitterBuffer) run(ctx context.Context, apply func([]color.RGBA)) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if frame, ok := j.pop(); ok {
+				apply(frame)
+			}
+		}
+	}
+}
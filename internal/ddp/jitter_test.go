@@ -0,0 +1,141 @@
+package ddp
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+func TestJitterBufferReleasesAtCadence(t *testing.T) {
+	const interval = 30 * time.Millisecond
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetJitterBuffer(interval)
+
+	// Deliver a burst of four pushed frames back-to-back
+	for i := 1; i <= 4; i++ {
+		v := byte(i * 10)
+		feedPacket(t, s, buildPacket(FlagPush, uint8(i), 0, []byte{v, 0, 0, v, 0, 0}))
+	}
+
+	// Nothing should reach the display until the buffer releases it
+	if got := ledState.LEDs()[0]; got.R != 0 {
+		t.Fatalf("expected buffered frame not to be displayed yet, got R=%d", got.R)
+	}
+	if s.jitter.Len() != 4 {
+		t.Fatalf("expected 4 buffered frames, got %d", s.jitter.Len())
+	}
+
+	type release struct {
+		at    time.Time
+		frame []color.RGBA
+	}
+	released := make(chan release, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.jitter.run(ctx, func(frame []color.RGBA) {
+		released <- release{at: time.Now(), frame: frame}
+	})
+
+	var prev time.Time
+	for i := 1; i <= 4; i++ {
+		select {
+		case r := <-released:
+			if want := uint8(i * 10); r.frame[0].R != want {
+				t.Errorf("frame %d: R = %d, want %d", i, r.frame[0].R, want)
+			}
+			if !prev.IsZero() {
+				if gap := r.at.Sub(prev); gap < interval/2 {
+					t.Errorf("frame %d released %v after previous, want about %v", i, gap, interval)
+				}
+			}
+			prev = r.at
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for frame %d", i)
+		}
+	}
+}
+
+func TestJitterBufferPartialUpdate(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	ledState := state.NewLEDState(6, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetJitterBuffer(time.Hour) // Frames stay queued for inspection
+
+	full := make([]byte, 0, 18)
+	for i := 0; i < 6; i++ {
+		full = append(full, 255, 0, 0)
+	}
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, full))
+	// Two green pixels at LEDs 2 and 3, before the red frame is released
+	feedPacket(t, s, buildPacket(FlagPush, 2, 6, []byte{0, 255, 0, 0, 255, 0}))
+
+	if _, ok := s.jitter.pop(); !ok {
+		t.Fatal("expected the full frame to be queued")
+	}
+	frame, ok := s.jitter.pop()
+	if !ok {
+		t.Fatal("expected the partial frame to be queued")
+	}
+	for i, c := range frame {
+		want := red
+		if i == 2 || i == 3 {
+			want = green
+		}
+		if c != want {
+			t.Errorf("LED %d = %v, want %v", i, c, want)
+		}
+	}
+}
+
+func TestJitterBufferDropsOnOverflow(t *testing.T) {
+	j := newJitterBuffer(time.Millisecond, 2)
+
+	for i := 0; i < 3; i++ {
+		j.push([]color.RGBA{{R: uint8(i)}})
+	}
+
+	if j.Len() != 2 {
+		t.Fatalf("expected 2 buffered frames, got %d", j.Len())
+	}
+	if j.Dropped() != 1 {
+		t.Errorf("expected 1 dropped frame, got %d", j.Dropped())
+	}
+
+	// The oldest frame is the one dropped
+	frame, _ := j.pop()
+	if frame[0].R != 1 {
+		t.Errorf("expected oldest remaining frame to be 1, got %d", frame[0].R)
+	}
+}
+
+func TestJitterBufferStagesUntilPush(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetJitterBuffer(time.Hour)
+
+	// Once a sender has used push, frames are only committed on push
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{0, 0, 0, 0, 0, 0}))
+	if _, ok := s.jitter.pop(); !ok {
+		t.Fatal("expected the initial pushed frame to be queued")
+	}
+
+	// First half of the frame without push, second half with push
+	feedPacket(t, s, buildPacket(0, 2, 0, []byte{255, 0, 0}))
+	if s.jitter.Len() != 0 {
+		t.Fatalf("expected no frames queued before push, got %d", s.jitter.Len())
+	}
+	feedPacket(t, s, buildPacket(FlagPush, 3, 3, []byte{0, 255, 0}))
+
+	frame, ok := s.jitter.pop()
+	if !ok {
+		t.Fatal("expected a frame to be queued after push")
+	}
+	if frame[0] != (color.RGBA{255, 0, 0, 255}) || frame[1] != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("unexpected committed frame: %v", frame)
+	}
+}
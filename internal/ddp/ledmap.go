@@ -0,0 +1,45 @@
+package ddp
+
+import "fmt"
+
+// SetLEDMaps loads WLED ledmaps keyed by number, each listing the physical
+// LED for every logical index, and selects map 0. With no map 0, pixels are
+// written unmapped until another is selected.
+func (s *Server) SetLEDMaps(maps map[int][]int) {
+	loaded := make(map[int][]int, len(maps))
+	for n, m := range maps {
+		loaded[n] = append([]int(nil), m...)
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.ledMaps = loaded
+	s.ledMap = 0
+}
+
+// SelectLEDMap switches DDP routing to ledmap n, like WLED's {"ledmap":n}.
+// Map 0 is always accepted, meaning no remapping when none was loaded.
+func (s *Server) SelectLEDMap(n int) error {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	if _, ok := s.ledMaps[n]; !ok && n != 0 {
+		return fmt.Errorf("invalid ledmap %d. No ledmap%d.json is loaded", n, n)
+	}
+	s.ledMap = n
+	return nil
+}
+
+// LEDMap returns the number of the selected ledmap
+func (s *Server) LEDMap() int {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.ledMap
+}
+
+// mapIndex returns the physical LED for logical index i under table. Indices
+// past the end of a short map are left as they are, as in WLED.
+func mapIndex(table []int, i int) int {
+	if i < len(table) {
+		return table[i]
+	}
+	return i
+}
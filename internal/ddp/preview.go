@@ -0,0 +1,30 @@
+package ddp
+
+import "wled-simulator/internal/state"
+
+// NewPreview returns a server for a second stream on port that writes to its
+// own state, so it can be compared with the main output without affecting it.
+// It decodes pixels the same way as s; the host is shared but the jitter
+// buffer, history and commit handler are not.
+func (s *Server) NewPreview(port int, st *state.LEDState) *Server {
+	p := NewServer(port, st)
+	p.host = s.host
+
+	s.cfgMu.RLock()
+	p.bytesPerPixel = s.bytesPerPixel
+	p.overflow = s.overflow
+	p.colorOrder = s.colorOrder
+	p.endian = s.endian
+	p.seqCheck = s.seqCheck
+	p.devSegments = s.devSegments
+	p.ledMaps = s.ledMaps
+	p.ledMap = s.ledMap
+	s.cfgMu.RUnlock()
+
+	p.wrap = s.wrap
+	p.strictAlign = s.strictAlign
+	p.checksum = s.checksum
+	p.separateWhite = s.separateWhite
+	p.lenientVer = s.lenientVer
+	return p
+}
@@ -0,0 +1,37 @@
+package ddp
+
+import (
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestPreviewIsolatedFromMain(t *testing.T) {
+	mainState := state.NewLEDState(2, "#000000")
+	main := NewServer(4048, mainState)
+	main.SetColorOrder("GRB")
+
+	previewState := state.NewLEDState(2, "#000000")
+	preview := main.NewPreview(4049, previewState)
+
+	feedPacket(t, preview, buildPacket(FlagPush, 1, 0, []byte{255, 0, 0, 255, 0, 0}))
+
+	// The preview decodes like the main server, GRB here
+	for i, c := range previewState.LEDs() {
+		if c != (color.RGBA{0, 255, 0, 255}) {
+			t.Errorf("preview LED %d = %v, want green", i, c)
+		}
+	}
+	for i, c := range mainState.LEDs() {
+		if c != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("main LED %d = %v, want unchanged black", i, c)
+		}
+	}
+	if mainState.IsLive() {
+		t.Error("expected the preview stream not to mark the main output live")
+	}
+	if _, ok := main.LastFrame(); ok {
+		t.Error("expected no frame committed on the main server")
+	}
+}
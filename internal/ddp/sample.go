@@ -0,0 +1,78 @@
+package ddp
+
+import "fmt"
+
+// Endian is the byte order of 16-bit samples in a DDP payload
+type Endian int
+
+const (
+	EndianBig    Endian = iota // Most significant byte first, as the DDP spec requires
+	EndianLittle               // Least significant byte first, sent by some senders
+)
+
+// ParseEndian converts "big" or "little" to an Endian
+func ParseEndian(s string) (Endian, error) {
+	switch s {
+	case "big":
+		return EndianBig, nil
+	case "little":
+		return EndianLittle, nil
+	}
+	return EndianBig, fmt.Errorf("invalid endianness '%s'. Must be 'big' or 'little'", s)
+}
+
+func (e Endian) String() string {
+	if e == EndianLittle {
+		return "little"
+	}
+	return "big"
+}
+
+// sampleFormat describes how pixel elements are laid out in a payload
+type sampleFormat struct {
+	width  int  // Bytes per element: 1 for 8-bit or 2 for 16-bit samples
+	gray   bool // One element per pixel, shown on all three channels
+	endian Endian
+}
+
+// payloadFormat returns the sample layout for header's data type
+func payloadFormat(header *DDPHeader, endian Endian) sampleFormat {
+	f := sampleFormat{width: 1, gray: header.DataType.Type == TypeGrayscale, endian: endian}
+	if header.DataType.Size == Size16Bit {
+		f.width = 2
+	}
+	return f
+}
+
+// pixelSize is the number of payload bytes in one pixel of channels elements
+func (f sampleFormat) pixelSize(channels int) int {
+	if f.gray {
+		channels = 1
+	}
+	return channels * f.width
+}
+
+// pixel returns the 8-bit channel values of one raw pixel. 16-bit samples are
+// scaled down to their most significant byte and grayscale is spread over R, G
+// and B. 8-bit colour pixels are returned as they are; otherwise buf holds the
+// result.
+func (f sampleFormat) pixel(raw []byte, buf *[4]byte) []byte {
+	if f.width == 1 && !f.gray {
+		return raw
+	}
+	n := len(raw) / f.width
+	for i := 0; i < n; i++ {
+		if f.width == 1 {
+			buf[i] = raw[i]
+		} else if f.endian == EndianLittle {
+			buf[i] = raw[2*i+1]
+		} else {
+			buf[i] = raw[2*i]
+		}
+	}
+	if f.gray {
+		buf[1], buf[2] = buf[0], buf[0]
+		n = 3
+	}
+	return buf[:n]
+}
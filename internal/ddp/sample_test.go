@@ -0,0 +1,70 @@
+package ddp
+
+import (
+	"image/color"
+	"testing"
+
+	"wled-simulator/internal/state"
+)
+
+func TestParseEndian(t *testing.T) {
+	for _, s := range []string{"big", "little"} {
+		e, err := ParseEndian(s)
+		if err != nil || e.String() != s {
+			t.Errorf("ParseEndian(%q) = %v, %v", s, e, err)
+		}
+	}
+	if _, err := ParseEndian("middle"); err == nil {
+		t.Error("expected error for 'middle'")
+	}
+}
+
+func TestDecode16BitEndian(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType byte
+		payload  []byte
+		big      color.RGBA
+		little   color.RGBA
+	}{
+		{
+			name:     "RGB",
+			dataType: TypeRGB<<3 | Size16Bit,
+			payload:  []byte{0x12, 0x34, 0xAB, 0xCD, 0x00, 0xFF},
+			big:      color.RGBA{0x12, 0xAB, 0x00, 255},
+			little:   color.RGBA{0x34, 0xCD, 0xFF, 255},
+		},
+		{
+			name:     "grayscale",
+			dataType: TypeGrayscale<<3 | Size16Bit,
+			payload:  []byte{0x80, 0x01},
+			big:      color.RGBA{0x80, 0x80, 0x80, 255},
+			little:   color.RGBA{0x01, 0x01, 0x01, 255},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, endian := range []Endian{EndianBig, EndianLittle} {
+			t.Run(tt.name+"/"+endian.String(), func(t *testing.T) {
+				ledState := state.NewLEDState(2, "#000000")
+				s := NewServer(4048, ledState)
+				s.SetEndian(endian)
+
+				data := buildPacket(FlagPush, 1, 0, tt.payload)
+				data[2] = tt.dataType
+				feedPacket(t, s, data)
+
+				want := tt.big
+				if endian == EndianLittle {
+					want = tt.little
+				}
+				if got := ledState.LEDs()[0]; got != want {
+					t.Errorf("LED 0 = %v, want %v", got, want)
+				}
+				if got := ledState.LEDs()[1]; got != (color.RGBA{0, 0, 0, 255}) {
+					t.Errorf("LED 1 = %v, want untouched by a single pixel", got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package ddp
+
+import (
+	"sort"
+	"time"
+)
+
+// fpsWindow is how often a sender's frame rate is recalculated
+const fpsWindow = time.Second
+
+// SenderStats describes one DDP source seen within the live timeout
+type SenderStats struct {
+	Addr     string
+	LastSeen time.Time
+	Packets  int
+	Frames   int     // Committed frames
+	FPS      float64 // Committed frames per second over the last window
+}
+
+// sender accumulates the stats for one source address
+type sender struct {
+	stats        SenderStats
+	windowStart  time.Time
+	windowFrames int
+}
+
+// recordSender counts a packet from addr, and a committed frame if frame is set
+func (s *Server) recordSender(addr string, frame bool) {
+	now := time.Now()
+	s.sendersMu.Lock()
+	defer s.sendersMu.Unlock()
+
+	if s.senders == nil {
+		s.senders = make(map[string]*sender)
+	}
+	snd, ok := s.senders[addr]
+	if !ok {
+		snd = &sender{stats: SenderStats{Addr: addr}, windowStart: now}
+		s.senders[addr] = snd
+	}
+	snd.stats.LastSeen = now
+	if !frame {
+		snd.stats.Packets++
+		return
+	}
+	snd.stats.Frames++
+	snd.windowFrames++
+	if elapsed := now.Sub(snd.windowStart); elapsed >= fpsWindow {
+		snd.stats.FPS = float64(snd.windowFrames) / elapsed.Seconds()
+		snd.windowStart = now
+		snd.windowFrames = 0
+	}
+}
+
+// Senders returns the sources seen within the live timeout, sorted by
+// address. Older sources are forgotten.
+func (s *Server) Senders() []SenderStats {
+	now := time.Now()
+	timeout := s.state.LiveTimeout()
+	s.sendersMu.Lock()
+	defer s.sendersMu.Unlock()
+
+	out := make([]SenderStats, 0, len(s.senders))
+	for addr, snd := range s.senders {
+		if now.Sub(snd.stats.LastSeen) > timeout {
+			delete(s.senders, addr)
+			continue
+		}
+		stats := snd.stats
+		if now.Sub(snd.windowStart) > 2*fpsWindow {
+			stats.FPS = 0 // No frames for a while
+		}
+		out = append(out, stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Addr < out[j].Addr })
+	return out
+}
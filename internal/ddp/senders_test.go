@@ -0,0 +1,52 @@
+package ddp
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+func TestSendersTracksEachSource(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	ledState.SetLiveTimeout(100 * time.Millisecond)
+	s := NewServer(4048, ledState)
+
+	a := &net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}
+	b := &net.UDPAddr{IP: net.ParseIP("10.0.0.2"), Port: 5000}
+	send := func(addr *net.UDPAddr, flags, seq uint8) {
+		t.Helper()
+		data := buildPacket(flags, seq, 0, []byte{255, 0, 0})
+		header, err := ParseHeader(data)
+		if err != nil {
+			t.Fatalf("ParseHeader failed: %v", err)
+		}
+		if err := s.processPacket(header, data, addr); err != nil {
+			t.Fatalf("processPacket failed: %v", err)
+		}
+	}
+
+	send(a, FlagPush, 1)
+	send(a, FlagPush, 2)
+	send(b, 0, 3)
+	send(b, FlagPush, 4)
+
+	senders := s.Senders()
+	if len(senders) != 2 {
+		t.Fatalf("got %d senders, want 2: %+v", len(senders), senders)
+	}
+	if got := senders[0]; got.Addr != "10.0.0.1" || got.Packets != 2 || got.Frames != 2 {
+		t.Errorf("sender 0 = %+v, want 10.0.0.1 with 2 packets and 2 frames", got)
+	}
+	if got := senders[1]; got.Addr != "10.0.0.2" || got.Packets != 2 || got.Frames != 1 {
+		t.Errorf("sender 1 = %+v, want 10.0.0.2 with 2 packets and 1 frame", got)
+	}
+
+	// Sources not seen within the live timeout are pruned
+	time.Sleep(150 * time.Millisecond)
+	send(b, FlagPush, 5)
+	if senders := s.Senders(); len(senders) != 1 || senders[0].Addr != "10.0.0.2" {
+		t.Errorf("senders after timeout = %+v, want only 10.0.0.2", senders)
+	}
+}
@@ -0,0 +1,52 @@
+package ddp
+
+import (
+	"net"
+	"time"
+)
+
+// sourceSequence is the duplicate tracking for one sender
+type sourceSequence struct {
+	last uint8     // Sequence number of the sender's previous packet
+	seen time.Time // When the sender's previous packet arrived
+}
+
+// sequenceFor returns the last sequence number seen from addr, to be checked
+// and updated by the packet received at now. Senders are told apart by IP,
+// and a sender silent for longer than the live timeout starts afresh, so one
+// that reconnects with its counter back at the last value isn't taken for a
+// duplicate. Callers hold seqMu until they are done with the result.
+func (s *Server) sequenceFor(addr *net.UDPAddr, now time.Time) *uint8 {
+	key := ""
+	if addr != nil {
+		key = addr.IP.String()
+	}
+
+	seq, ok := s.sequences[key]
+	if !ok {
+		seq = &sourceSequence{}
+		s.sequences[key] = seq
+	} else if now.Sub(seq.seen) > s.state.LiveTimeout() {
+		seq.last = 0
+	}
+	seq.seen = now
+	return &seq.last
+}
+
+// checkHeader validates a header received from addr, including the duplicate
+// check against the sender's previous packet unless it is disabled. seqMu is
+// held throughout so packets read concurrently, such as from UDP and
+// ServeReader at once, can't interleave their check and update.
+func (s *Server) checkHeader(header *DDPHeader, addr *net.UDPAddr) error {
+	s.cfgMu.RLock()
+	seqCheck := s.seqCheck
+	s.cfgMu.RUnlock()
+
+	var lastSequence *uint8
+	if seqCheck {
+		s.seqMu.Lock()
+		defer s.seqMu.Unlock()
+		lastSequence = s.sequenceFor(addr, time.Now())
+	}
+	return validateHeader(header, lastSequence, s.mappedDevice(header.DeviceID))
+}
@@ -0,0 +1,72 @@
+package ddp
+
+import (
+	"image/color"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+func TestSequenceResetsAfterLiveTimeout(t *testing.T) {
+	ledState := state.NewLEDState(1, "#000000")
+	ledState.SetLiveTimeout(50 * time.Millisecond)
+	s := NewServer(4048, ledState)
+	sender := &net.UDPAddr{IP: net.IPv4(192, 0, 2, 10), Port: 5000}
+
+	send := func(addr *net.UDPAddr, seq uint8, c color.RGBA) {
+		s.handlePacket(buildPacket(FlagPush, seq, 0, []byte{c.R, c.G, c.B}), addr)
+	}
+	red, green, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}
+
+	send(sender, 5, red)
+	send(sender, 5, green) // Duplicate while connected
+	if got := ledState.LEDs()[0]; got != red {
+		t.Fatalf("LED = %v, want the duplicate dropped", got)
+	}
+
+	// Another sender has its own sequence numbers
+	send(&net.UDPAddr{IP: net.IPv4(192, 0, 2, 11), Port: 5000}, 5, green)
+	if got := ledState.LEDs()[0]; got != green {
+		t.Fatalf("LED = %v, want the second sender's packet applied", got)
+	}
+
+	// Reconnecting after the live timeout, from a new port, with the
+	// counter back where it was
+	time.Sleep(100 * time.Millisecond)
+	send(&net.UDPAddr{IP: sender.IP, Port: 5001}, 5, blue)
+	if got := ledState.LEDs()[0]; got != blue {
+		t.Errorf("LED = %v, want the first frame after reconnecting applied", got)
+	}
+}
+
+func TestSequenceConcurrentSources(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(1, "#000000"))
+
+	// UDP and ServeReader may feed the same server at once
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		addr := &net.UDPAddr{IP: net.IPv4(192, 0, 2, byte(10+i)), Port: 5000}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seq := 1; seq <= 15; seq++ {
+				s.handlePacket(buildPacket(FlagPush, uint8(seq), 0, []byte{1, 2, 3}), addr)
+			}
+		}()
+	}
+	wg.Wait()
+
+	s.seqMu.Lock()
+	defer s.seqMu.Unlock()
+	if len(s.sequences) != 4 {
+		t.Fatalf("tracking %d senders, want 4", len(s.sequences))
+	}
+	for ip, seq := range s.sequences {
+		if seq.last != 15 {
+			t.Errorf("sender %s last sequence = %d, want 15", ip, seq.last)
+		}
+	}
+}
@@ -6,39 +6,157 @@ import (
 	"image/color"
 	"log"
 	"net"
+	"strconv"
+	"sync"
+	"time"
 
 	"wled-simulator/internal/state"
 )
 
 type Server struct {
-	port         int
-	state        *state.LEDState
-	conn         *net.UDPConn
-	ctx          context.Context
-	cancel       context.CancelFunc
-	lastSequence uint8
-	verbose      bool
+	host          string // Bind host, empty for all interfaces
+	port          int
+	state         *state.LEDState
+	conn          *net.UDPConn
+	connMu        sync.Mutex // Protect conn, which is replaced when rebinding
+	ctx           context.Context
+	cancel        context.CancelFunc
+	sequences     map[string]*sourceSequence // Duplicate tracking per sender IP
+	seqMu         sync.Mutex                 // Protect sequences across the check and update of a packet
+	verbose       bool
+	bytesPerPixel int              // 3 for RGB, 4 for RGBW with white added into RGB
+	overflow      OverflowMode     // How channel sums above 255 are handled
+	colorOrder    ColorOrder       // Channel order of the first three bytes of each pixel
+	seqCheck      bool             // Reject packets repeating the previous sequence number
+	devSegments   map[DeviceID]int // Segment id fed by each extra device ID
+	endian        Endian           // Byte order of 16-bit samples
+	ledMaps       map[int][]int    // Loaded WLED ledmaps by number
+	ledMap        int              // Selected ledmap, unmapped if not loaded
+	cfgMu         sync.RWMutex     // Protect the decode settings, which may change at runtime
+	jitter        *jitterBuffer
+	staging       []color.RGBA // Frame being assembled until the next push
+	committed     []color.RGBA // Most recent frame queued to the jitter buffer
+	seenPush      bool         // Whether any sender has used the push flag
+	strictAlign   bool         // Report misaligned payloads as failed packets
+	checksum      bool         // Require a trailing CRC-32 on every payload
+	wrap          bool         // Wrap writes past the last LED to the start
+	separateWhite bool         // Store RGBW white apart from RGB instead of adding it
+	lenientVer    bool         // Accept version 0 headers as version 1
+	frameMu       sync.RWMutex // Protect lastFrame, history and the error counters
+	lastFrame     *Frame
+	history       *frameHistory // Recent committed frames, nil when disabled
+	misaligned    int           // Packets whose payload was not a whole number of pixels
+	badChecksums  int           // Packets dropped for a bad checksum
+	onCommit      func(Frame)
+	senders       map[string]*sender // Per-source stats, keyed by IP
+	sendersMu     sync.Mutex
+}
+
+// Frame is a snapshot of the most recently committed DDP frame
+type Frame struct {
+	LEDs     []color.RGBA
+	Source   string
+	Sequence uint8
+	Time     time.Time
 }
 
 func NewServer(port int, s *state.LEDState) *Server {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &Server{
-		port:    port,
-		state:   s,
-		ctx:     ctx,
-		cancel:  cancel,
-		verbose: false, // Disable verbose logging by default
+		port:          port,
+		state:         s,
+		ctx:           ctx,
+		cancel:        cancel,
+		sequences:     make(map[string]*sourceSequence),
+		verbose:       false, // Disable verbose logging by default
+		bytesPerPixel: 3,
+		colorOrder:    ColorOrderRGB,
+		seqCheck:      true,
+		overflow:      OverflowClamp,
+	}
+}
+
+// handlePacket parses, validates and processes one raw packet, reporting the
+// outcome as DDP activity. remoteAddr may be nil for packets not read from UDP.
+func (s *Server) handlePacket(data []byte, remoteAddr *net.UDPAddr) {
+	// Parse and validate header
+	header, err := parseHeader(data, s.lenientVer)
+	if err != nil {
+		s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
+		if s.verbose {
+			log.Printf("[DDP] Invalid packet from %s: %v", remoteAddr, err)
+		}
+		return
+	}
+
+	// Additional validation, skipping the duplicate check when disabled
+	if err := s.checkHeader(header, remoteAddr); err != nil {
+		s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
+		if s.verbose {
+			log.Printf("[DDP] Packet validation failed from %s: %v", remoteAddr, err)
+		}
+		return
 	}
+
+	// Process the packet
+	if err := s.processPacket(header, data, remoteAddr); err != nil {
+		s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
+		if s.verbose {
+			log.Printf("[DDP] Packet processing failed from %s: %v", remoteAddr, err)
+		}
+		return
+	}
+
+	s.state.ReportActivity(state.ActivityDDP, true) // Report successful DDP activity
 }
 
-// processPacket processes a validated DDP packet
-func (s *Server) processPacket(header *DDPHeader, data []byte) error {
+// processPacket processes a validated DDP packet received from remoteAddr
+func (s *Server) processPacket(header *DDPHeader, data []byte, remoteAddr *net.UDPAddr) (err error) {
 	headerSize := MinHeaderSize
 	if header.HasTimecode {
 		headerSize = MaxHeaderSize
 	}
 
 	payload := data[headerSize : headerSize+int(header.DataLength)]
+	if s.checksum {
+		if payload, err = s.verifyChecksum(payload); err != nil {
+			return err
+		}
+	}
+
+	s.cfgMu.RLock()
+	bpp, overflow, order, endian := s.bytesPerPixel, s.overflow, s.colorOrder, s.endian
+	table := s.ledMaps[s.ledMap]
+	s.cfgMu.RUnlock()
+	separateWhite := s.separateWhite
+	if s.state.RGBW() {
+		bpp, separateWhite = 4, true
+	}
+	if header.DataType.Type == TypeRGBW {
+		// The sender labels its pixels as four bytes, whatever is configured
+		bpp = 4
+	}
+	format := payloadFormat(header, endian)
+	size := format.pixelSize(bpp)
+
+	// Trailing bytes that don't make up a whole pixel are ignored
+	if extra := len(payload) % size; extra != 0 {
+		s.frameMu.Lock()
+		s.misaligned++
+		s.frameMu.Unlock()
+		if s.verbose {
+			log.Printf("[DDP] Payload length %d is not a multiple of %d bytes per pixel, ignoring %d trailing bytes",
+				len(payload), size, extra)
+		}
+		if s.strictAlign {
+			// The whole pixels are still applied
+			defer func() {
+				if err == nil {
+					err = fmt.Errorf("payload length %d is not a multiple of %d bytes per pixel", len(payload), size)
+				}
+			}()
+		}
+	}
 
 	if s.verbose {
 		typeStr := "undefined"
@@ -63,6 +181,20 @@ func (s *Server) processPacket(header *DDPHeader, data []byte) error {
 			header.DeviceID, header.DataOffset, header.DataLength)
 	}
 
+	// Replies are responses from another device, not commands for this one
+	if header.Reply {
+		if s.verbose {
+			log.Printf("[DDP] Ignoring reply packet from %s", remoteAddr)
+		}
+		return nil
+	}
+
+	// Any valid packet shows a sender is connected, even without pixels
+	s.state.SetConnected()
+	if remoteAddr != nil {
+		s.recordSender(remoteAddr.IP.String(), false)
+	}
+
 	// Handle query packets
 	if header.Query {
 		if s.verbose {
@@ -71,26 +203,70 @@ func (s *Server) processPacket(header *DDPHeader, data []byte) error {
 		return nil
 	}
 
-	// Mark that we're receiving live DDP data
-	s.state.SetLive()
+	// Only packets carrying pixels count as live; empty ones are heartbeats
+	if len(payload) >= size {
+		s.state.SetLive()
+	}
+	if remoteAddr != nil {
+		s.state.SetLastSource(remoteAddr.IP.String())
+	}
+
+	// While live override (lor) is on, the stream keeps the device live but
+	// its pixels are not shown
+	if s.state.LiveOverride() != state.LiveOverrideOff {
+		return nil
+	}
 
-	// Process RGB data
+	// With a jitter buffer, pixels are staged and only queued once the frame is pushed
+	setLED := s.state.SetLED
+	if s.jitter != nil {
+		// Seed a new frame from the last committed one, which may still be
+		// queued, so sparse updates leave the untouched pixels as they were
+		if s.staging == nil {
+			if s.committed != nil {
+				s.staging = make([]color.RGBA, len(s.committed))
+				copy(s.staging, s.committed)
+			} else {
+				s.staging = s.state.LEDs()
+			}
+		}
+		setLED = func(i int, c color.RGBA) {
+			s.staging[i] = c
+		}
+	}
+
+	// Process RGB data. Many simple senders leave the data type undefined
+	// (0x00) and send raw 8-bit RGB, which ValidateHeader has already limited
+	// to undefined or 8-bit sizes, so it decodes the same way.
 	leds := s.state.LEDs()
-	maxIndex := len(leds)
-	startIndex := int(header.DataOffset / 3) // Assuming 3 bytes per LED (RGB)
+	segments := s.state.Segments()
+	lo, hi, err := s.deviceRange(header.DeviceID, segments, len(leds))
+	if err != nil {
+		return err
+	}
+	maxIndex := hi - lo
+	startIndex := int(header.DataOffset) / size
 
 	pixelCount := 0
-	for i := 0; i+2 < len(payload); i += 3 {
-		ledIndex := startIndex + (i / 3)
+	var buf [4]byte
+	for i := 0; i+size <= len(payload); i += size {
+		ledIndex := startIndex + (i / size)
 		if ledIndex >= maxIndex {
-			break
-		}
-		s.state.SetLED(ledIndex, color.RGBA{
-			R: payload[i],
-			G: payload[i+1],
-			B: payload[i+2],
-			A: 255,
-		})
+			if !s.wrap || maxIndex == 0 {
+				break
+			}
+			ledIndex = state.Wrap(ledIndex, maxIndex)
+		}
+		pixel := format.pixel(payload[i:i+size], &buf)
+		index := mapIndex(table, routeIndex(segments, lo+ledIndex))
+		if index < 0 || index >= len(leds) {
+			continue
+		}
+		if separateWhite && len(pixel) == 4 {
+			s.state.SetWhite(index, pixel[3])
+			pixel = pixel[:3]
+		}
+		setLED(index, decodePixel(pixel, overflow, order))
 		pixelCount++
 	}
 
@@ -98,91 +274,270 @@ func (s *Server) processPacket(header *DDPHeader, data []byte) error {
 		log.Printf("[DDP] Updated %d LEDs starting at index %d", pixelCount, startIndex)
 	}
 
+	// Like WLED, a frame is committed on push, or on every packet until a
+	// sender has been seen using the push flag
+	if header.Push {
+		s.seenPush = true
+	}
+	if !header.Push && s.seenPush {
+		return nil
+	}
+
+	var frame []color.RGBA
+	if s.jitter != nil {
+		s.committed = s.staging
+		s.staging = nil
+		if !s.jitter.push(s.committed) && s.verbose {
+			log.Printf("[DDP] Jitter buffer full, dropped oldest frame")
+		}
+		frame = make([]color.RGBA, len(s.committed))
+		copy(frame, s.committed)
+	} else {
+		frame = s.state.LEDs()
+		s.state.CommitFrame()
+	}
+
+	source := ""
+	if remoteAddr != nil {
+		source = remoteAddr.String()
+	}
+	committed := &Frame{
+		LEDs:     frame,
+		Source:   source,
+		Sequence: header.Sequence,
+		Time:     time.Now(),
+	}
+	s.frameMu.Lock()
+	s.lastFrame = committed
+	if s.history != nil {
+		s.history.add(*committed)
+	}
+	s.frameMu.Unlock()
+
+	if remoteAddr != nil {
+		s.recordSender(remoteAddr.IP.String(), true)
+	}
+	if s.onCommit != nil {
+		s.onCommit(*committed)
+	}
+
 	return nil
 }
 
-// Start begins listening for DDP packets
-func (s *Server) Start() error {
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf(":%d", s.port))
-	if err != nil {
-		return err
+// routeIndex maps an LED index to where it is written, mirroring it within
+// its owning segment when that segment is reversed. Freezing and segment
+// brightness are applied by the state for the index returned.
+func routeIndex(segments []state.Segment, i int) int {
+	for _, seg := range segments {
+		if i >= seg.Start && i < seg.Stop {
+			if seg.Rev {
+				return seg.Start + seg.Stop - 1 - i
+			}
+			return i
+		}
 	}
-	conn, err := net.ListenUDP("udp", addr)
+	return i
+}
+
+// SetCommitHandler registers fn to be called with each committed frame
+func (s *Server) SetCommitHandler(fn func(Frame)) {
+	s.onCommit = fn
+}
+
+// SetStrictAlignment makes packets whose payload is not a whole number of
+// pixels count as failed DDP activity. Their whole pixels are still applied.
+func (s *Server) SetStrictAlignment(strict bool) {
+	s.strictAlign = strict
+}
+
+// SetWrap makes pixels past the last LED wrap around to the first instead of
+// being dropped, as suits LED rings
+func (s *Server) SetWrap(wrap bool) {
+	s.wrap = wrap
+}
+
+// SetSeparateWhite keeps the white byte of 4 byte pixels in the state's white
+// channel instead of adding it into RGB. White is written as it arrives, even
+// with a jitter buffer.
+func (s *Server) SetSeparateWhite(on bool) {
+	s.separateWhite = on
+}
+
+// SetLenientVersion accepts packets whose version bits are 0 as version 1,
+// for older senders that leave them unset. Other versions are still rejected.
+func (s *Server) SetLenientVersion(on bool) {
+	s.lenientVer = on
+}
+
+// Misaligned returns how many packets had trailing bytes that did not make up
+// a whole pixel
+func (s *Server) Misaligned() int {
+	s.frameMu.RLock()
+	defer s.frameMu.RUnlock()
+	return s.misaligned
+}
+
+// LastFrame returns the most recently committed frame, if any
+func (s *Server) LastFrame() (Frame, bool) {
+	s.frameMu.RLock()
+	defer s.frameMu.RUnlock()
+	if s.lastFrame == nil {
+		return Frame{}, false
+	}
+	return *s.lastFrame, true
+}
+
+// Start binds the UDP port and begins processing packets in the background.
+// Bind failures are returned before Start does, wrapping the underlying
+// error so callers can test for syscall.EADDRINUSE.
+func (s *Server) Start() error {
+	conn, err := s.listen()
 	if err != nil {
 		return err
 	}
-	s.conn = conn
-
-	// Start packet processing in a goroutine
-	errChan := make(chan error, 1)
-	go func() {
-		defer conn.Close()
-		buf := make([]byte, 1500)
-		for {
-			select {
-			case <-s.ctx.Done():
-				return
-			default:
-				n, remoteAddr, err := conn.ReadFromUDP(buf)
-				if err != nil {
-					if s.ctx.Err() != nil {
-						return // Normal shutdown
-					}
-					log.Printf("[DDP] UDP read error: %v", err)
-					continue
-				}
+	s.setConn(conn)
 
-				// Parse and validate header
-				header, err := ParseHeader(buf[:n])
-				if err != nil {
-					s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
-					if s.verbose {
-						log.Printf("[DDP] Invalid packet from %s: %v", remoteAddr, err)
-					}
-					continue
-				}
+	// Release buffered frames at a steady rate
+	if s.jitter != nil {
+		go s.jitter.run(s.ctx, s.releaseFrame)
+	}
 
-				// Additional validation
-				if err := ValidateHeader(header, &s.lastSequence); err != nil {
-					s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
-					if s.verbose {
-						log.Printf("[DDP] Packet validation failed from %s: %v", remoteAddr, err)
-					}
-					continue
-				}
+	// Process packets in the background, rebinding if the socket fails
+	go s.supervise(conn)
 
-				// Process the packet
-				if err := s.processPacket(header, buf[:n]); err != nil {
-					s.state.ReportActivity(state.ActivityDDP, false) // Report failed DDP activity
-					if s.verbose {
-						log.Printf("[DDP] Packet processing failed from %s: %v", remoteAddr, err)
-					}
-					continue
-				}
+	return nil
+}
 
-				s.state.ReportActivity(state.ActivityDDP, true) // Report successful DDP activity
-			}
-		}
-	}()
+// releaseFrame shows a frame leaving the jitter buffer, which is when it
+// reaches the state as a whole
+func (s *Server) releaseFrame(frame []color.RGBA) {
+	s.state.SetLEDs(frame)
+	s.state.CommitFrame()
+}
 
-	// Wait a moment for any immediate startup errors
-	select {
-	case err := <-errChan:
-		return err
-	default:
-		return nil
+// listen binds the UDP socket for the server's host and port
+func (s *Server) listen() (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(s.host, strconv.Itoa(s.port)))
+	if err != nil {
+		return nil, err
 	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("listen on UDP %s: %w", addr, err)
+	}
+	return conn, nil
 }
 
 func (s *Server) Stop() error {
 	s.cancel()
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
 	if s.conn != nil {
 		return s.conn.Close()
 	}
 	return nil
 }
 
+// SetEndian sets the byte order 16-bit RGB and grayscale samples are read
+// in before being scaled to 8 bits. DDP specifies big-endian.
+func (s *Server) SetEndian(e Endian) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.endian = e
+}
+
+// SetBytesPerPixel sets the pixel size in the payload: 3 for RGB or 4 for RGBW
+func (s *Server) SetBytesPerPixel(bpp int) error {
+	if bpp != 3 && bpp != 4 {
+		return fmt.Errorf("invalid bytes per pixel %d. Must be 3 (RGB) or 4 (RGBW)", bpp)
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.bytesPerPixel = bpp
+	return nil
+}
+
+// SetOverflow sets how channel sums above 255 are resolved when decoding
+func (s *Server) SetOverflow(mode OverflowMode) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.overflow = mode
+}
+
+// SetColorOrder sets the channel order of incoming pixels
+func (s *Server) SetColorOrder(order ColorOrder) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.colorOrder = order
+}
+
+// SetSequenceCheck enables or disables rejecting packets that repeat the
+// previous non-zero sequence number. It is enabled by default.
+func (s *Server) SetSequenceCheck(on bool) {
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.seqCheck = on
+}
+
+// DecodeConfig is the set of decoding options that can be changed while running
+type DecodeConfig struct {
+	BytesPerPixel int    `json:"bpp"`
+	ColorOrder    string `json:"order"`
+	SeqCheck      bool   `json:"seqcheck"`
+}
+
+// DecodeConfig returns the current decoding options
+func (s *Server) DecodeConfig() DecodeConfig {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	bpp := s.bytesPerPixel
+	if s.state.RGBW() {
+		bpp = 4
+	}
+	return DecodeConfig{
+		BytesPerPixel: bpp,
+		ColorOrder:    string(s.colorOrder),
+		SeqCheck:      s.seqCheck,
+	}
+}
+
+// SetDecodeConfig validates and applies all of cfg, leaving the current
+// options unchanged if any value is invalid
+func (s *Server) SetDecodeConfig(cfg DecodeConfig) error {
+	if cfg.BytesPerPixel != 3 && cfg.BytesPerPixel != 4 {
+		return fmt.Errorf("invalid bytes per pixel %d. Must be 3 (RGB) or 4 (RGBW)", cfg.BytesPerPixel)
+	}
+	if cfg.BytesPerPixel != 4 && s.state.RGBW() {
+		return fmt.Errorf("invalid bytes per pixel %d. RGBW LEDs need 4", cfg.BytesPerPixel)
+	}
+	order, err := ParseColorOrder(cfg.ColorOrder)
+	if err != nil {
+		return err
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.bytesPerPixel = cfg.BytesPerPixel
+	s.colorOrder = order
+	s.seqCheck = cfg.SeqCheck
+	return nil
+}
+
+// SetHost restricts the listener to a single local address. Must be called before Start.
+func (s *Server) SetHost(host string) {
+	s.host = host
+}
+
 // SetVerbose enables or disables verbose logging
 func (s *Server) SetVerbose(verbose bool) {
 	s.verbose = verbose
 }
+
+// SetJitterBuffer enables a jitter buffer that releases pushed frames one per
+// interval. A zero interval disables buffering. Must be called before Start.
+func (s *Server) SetJitterBuffer(interval time.Duration) {
+	if interval <= 0 {
+		s.jitter = nil
+		return
+	}
+	s.jitter = newJitterBuffer(interval, DefaultJitterCapacity)
+}
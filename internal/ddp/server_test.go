@@ -1,13 +1,46 @@
 package ddp
 
 import (
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"net"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
 	"wled-simulator/internal/state"
 )
 
+// buildPacket assembles a version 1 RGB DDP packet with the given flags and payload
+func buildPacket(flags, seq uint8, offset uint32, payload []byte) []byte {
+	data := make([]byte, MinHeaderSize+len(payload))
+	data[0] = (DDPVersion << FlagVersionShift) | flags
+	data[1] = seq
+	data[2] = 0x0B // RGB, 8 bits per element
+	data[3] = byte(DeviceIDDefault)
+	binary.BigEndian.PutUint32(data[4:8], offset)
+	binary.BigEndian.PutUint16(data[8:10], uint16(len(payload)))
+	copy(data[MinHeaderSize:], payload)
+	return data
+}
+
+// feedPacket parses, validates and processes a packet as the UDP loop would
+func feedPacket(t *testing.T, s *Server, data []byte) {
+	t.Helper()
+	header, err := ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if err := s.checkHeader(header, nil); err != nil {
+		t.Fatalf("checkHeader failed: %v", err)
+	}
+	if err := s.processPacket(header, data, nil); err != nil {
+		t.Fatalf("processPacket failed: %v", err)
+	}
+}
+
 func TestServerSetVerbose(t *testing.T) {
 	s := NewServer(4048, state.NewLEDState(10, "#000000"))
 
@@ -80,3 +113,347 @@ func TestPortCollision(t *testing.T) {
 	srv1.Stop()
 	srv2.Stop()
 }
+
+func TestStartReportsAddressInUse(t *testing.T) {
+	const testPort = 4050
+	ledState := state.NewLEDState(10, "#000000")
+
+	srv1 := NewServer(testPort, ledState)
+	if err := srv1.Start(); err != nil {
+		t.Fatalf("First server failed: %v", err)
+	}
+	defer srv1.Stop()
+
+	// No delay: the bind error must come back from Start itself
+	srv2 := NewServer(testPort, ledState)
+	defer srv2.Stop()
+	err := srv2.Start()
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("Expected wrapped EADDRINUSE, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "4050") {
+		t.Errorf("Expected error to name the port, got: %v", err)
+	}
+}
+
+func TestServerSetHost(t *testing.T) {
+	s := NewServer(4062, state.NewLEDState(10, "#000000"))
+	s.SetHost("127.0.0.1")
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer s.Stop()
+
+	addr := s.conn.LocalAddr().(*net.UDPAddr)
+	if !addr.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("listening on %v, want 127.0.0.1", addr.IP)
+	}
+	if addr.Port != 4062 {
+		t.Errorf("listening on port %d, want 4062", addr.Port)
+	}
+}
+
+func TestLastFrame(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+
+	if _, ok := s.LastFrame(); ok {
+		t.Fatal("expected no frame before any packet")
+	}
+
+	feedPacket(t, s, buildPacket(FlagPush, 3, 0, []byte{10, 20, 30, 40, 50, 60}))
+
+	frame, ok := s.LastFrame()
+	if !ok {
+		t.Fatal("expected a frame after a pushed packet")
+	}
+	if frame.Sequence != 3 {
+		t.Errorf("Sequence = %d, want 3", frame.Sequence)
+	}
+	if frame.LEDs[1] != (color.RGBA{40, 50, 60, 255}) {
+		t.Errorf("LEDs[1] = %v, want {40 50 60 255}", frame.LEDs[1])
+	}
+
+	// Once push has been seen, packets without it do not commit
+	feedPacket(t, s, buildPacket(0, 4, 0, []byte{1, 1, 1}))
+	if frame, _ := s.LastFrame(); frame.Sequence != 3 {
+		t.Errorf("expected unpushed packet not to commit, got sequence %d", frame.Sequence)
+	}
+}
+
+func TestFrozenSegmentSkipsDDPWrites(t *testing.T) {
+	ledState := state.NewLEDState(4, "#000000")
+	ledState.SetSegment(0, state.Segment{ID: 0, Start: 0, Stop: 2, On: true, Frz: true})
+	ledState.SetSegment(1, state.Segment{ID: 1, Start: 2, Stop: 4, On: true})
+	s := NewServer(4048, ledState)
+
+	red := []byte{255, 0, 0}
+	payload := append(append(append(append([]byte{}, red...), red...), red...), red...)
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, payload))
+
+	black := color.RGBA{0, 0, 0, 255}
+	for i, want := range []color.RGBA{black, black, {255, 0, 0, 255}, {255, 0, 0, 255}} {
+		if got := ledState.LEDs()[i]; got != want {
+			t.Errorf("LED %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestMisalignedPayload(t *testing.T) {
+	ledState := state.NewLEDState(4, "#000000")
+	s := NewServer(4048, ledState)
+
+	// 7 bytes is two RGB pixels and one stray byte
+	payload := []byte{255, 0, 0, 0, 255, 0, 9}
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, payload))
+	if got := s.Misaligned(); got != 1 {
+		t.Errorf("Misaligned() = %d, want 1", got)
+	}
+	if got := ledState.LEDs()[1]; got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("LED 1 = %v, want whole pixels still applied", got)
+	}
+
+	// In strict mode the packet is reported as failed
+	s.SetStrictAlignment(true)
+	data := buildPacket(FlagPush, 2, 0, payload)
+	header, err := ParseHeader(data)
+	if err != nil {
+		t.Fatalf("ParseHeader failed: %v", err)
+	}
+	if err := s.processPacket(header, data, nil); err == nil {
+		t.Error("expected an error for a misaligned payload in strict mode")
+	}
+	if got := s.Misaligned(); got != 2 {
+		t.Errorf("Misaligned() = %d, want 2", got)
+	}
+
+	// Aligned payloads are unaffected
+	feedPacket(t, s, buildPacket(FlagPush, 3, 0, payload[:6]))
+	if got := s.Misaligned(); got != 2 {
+		t.Errorf("Misaligned() = %d after aligned packet, want 2", got)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	// Three pixels starting at LED 2 of 4; the last one is past the end
+	payload := []byte{255, 0, 0, 255, 0, 0, 0, 0, 255}
+
+	ledState := state.NewLEDState(4, "#000000")
+	s := NewServer(4048, ledState)
+	feedPacket(t, s, buildPacket(FlagPush, 1, 6, payload))
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v without wrap, want untouched black", got)
+	}
+
+	s.SetWrap(true)
+	feedPacket(t, s, buildPacket(FlagPush, 2, 6, payload))
+	leds := ledState.LEDs()
+	if leds[2] != red || leds[3] != red {
+		t.Errorf("LEDs 2-3 = %v %v, want red", leds[2], leds[3])
+	}
+	if leds[0] != blue {
+		t.Errorf("LED 0 = %v, want the wrapped tail %v", leds[0], blue)
+	}
+}
+
+func TestLenientVersion(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	packet := buildPacket(FlagPush, 1, 0, []byte{255, 0, 0})
+	packet[0] &^= FlagVersionMask // Version 0, as some older senders send
+
+	ledState := state.NewLEDState(1, "#000000")
+	s := NewServer(4048, ledState)
+	s.handlePacket(packet, nil)
+	if got := ledState.LEDs()[0]; got == red {
+		t.Error("version 0 packet applied in strict mode, want rejected")
+	}
+
+	s.SetLenientVersion(true)
+	s.handlePacket(packet, nil)
+	if got := ledState.LEDs()[0]; got != red {
+		t.Errorf("LED 0 = %v in lenient mode, want %v", got, red)
+	}
+
+	// Other versions are still rejected
+	packet[0] = (2 << FlagVersionShift) | FlagPush
+	if _, err := parseHeader(packet, true); err == nil {
+		t.Error("expected version 2 to be rejected in lenient mode")
+	}
+}
+
+func TestZeroLEDs(t *testing.T) {
+	ledState := state.NewLEDState(0, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetWrap(true)
+	s.SetJitterBuffer(time.Millisecond)
+	defer s.Stop()
+
+	payload := []byte{255, 0, 0, 0, 255, 0}
+	for seq, offset := range []uint32{0, 3, 300} {
+		data := buildPacket(FlagPush, uint8(seq+1), offset, payload)
+		header, err := ParseHeader(data)
+		if err != nil {
+			t.Fatalf("ParseHeader failed: %v", err)
+		}
+		if err := s.processPacket(header, data, nil); err != nil {
+			t.Errorf("processPacket at offset %d failed: %v", offset, err)
+		}
+	}
+	if leds := ledState.LEDs(); len(leds) != 0 {
+		t.Errorf("expected no LEDs, got %d", len(leds))
+	}
+}
+
+func TestReplyPacketIgnored(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+
+	feedPacket(t, s, buildPacket(FlagPush|FlagReply, 3, 0, []byte{255, 0, 0, 255, 0, 0}))
+	for i, c := range ledState.LEDs() {
+		if c != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("LED %d = %v after a reply packet, want unchanged black", i, c)
+		}
+	}
+	if ledState.IsConnected() {
+		t.Error("expected a reply packet not to mark a sender connected")
+	}
+
+	// The reply's sequence is not taken as this device's last sequence
+	feedPacket(t, s, buildPacket(FlagPush, 3, 0, []byte{255, 0, 0}))
+	if got := ledState.LEDs()[0]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v, want red from the following data packet", got)
+	}
+}
+
+func TestUndefinedTypeDecodesAsRGB(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+
+	for _, dataType := range []byte{0x00, 0x03} { // Undefined size, then 8-bit
+		data := buildPacket(FlagPush, dataType+1, 0, []byte{10, 20, 30, 40, 50, 60})
+		data[2] = dataType
+		feedPacket(t, s, data)
+		leds := ledState.LEDs()
+		if leds[0] != (color.RGBA{10, 20, 30, 255}) || leds[1] != (color.RGBA{40, 50, 60, 255}) {
+			t.Errorf("data type 0x%02X decoded as %v, want raw RGB", dataType, leds)
+		}
+		ledState.SetLEDs([]color.RGBA{{0, 0, 0, 255}, {0, 0, 0, 255}})
+	}
+}
+
+func TestCommitHandler(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(2, "#000000"))
+
+	var frames []Frame
+	s.SetCommitHandler(func(f Frame) {
+		frames = append(frames, f)
+	})
+
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{1, 2, 3}))
+	feedPacket(t, s, buildPacket(0, 2, 3, []byte{4, 5, 6})) // Not pushed
+	feedPacket(t, s, buildPacket(FlagPush, 3, 3, []byte{7, 8, 9}))
+
+	if len(frames) != 2 {
+		t.Fatalf("handler called %d times, want once per pushed frame", len(frames))
+	}
+	if got := frames[1].LEDs[1]; got != (color.RGBA{7, 8, 9, 255}) {
+		t.Errorf("second frame LED 1 = %v, want {7 8 9 255}", got)
+	}
+}
+
+func TestStateOnFrame(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+
+	var frames [][]color.RGBA
+	ledState.SetOnFrame(func(leds []color.RGBA) {
+		// The state is unlocked, so the callback can use it
+		if !ledState.IsLive() {
+			t.Error("expected state to be live during callback")
+		}
+		frames = append(frames, leds)
+	})
+
+	feedPacket(t, s, buildPacket(0, 1, 0, []byte{1, 2, 3}))
+	feedPacket(t, s, buildPacket(FlagPush, 2, 3, []byte{4, 5, 6}))
+	feedPacket(t, s, buildPacket(0, 3, 0, []byte{7, 8, 9})) // Not pushed
+
+	if len(frames) != 2 {
+		t.Fatalf("callback called %d times, want 2", len(frames))
+	}
+	want := []color.RGBA{{1, 2, 3, 255}, {4, 5, 6, 255}}
+	if frames[1][0] != want[0] || frames[1][1] != want[1] {
+		t.Errorf("committed frame = %v, want %v", frames[1], want)
+	}
+}
+
+func TestSegmentRouting(t *testing.T) {
+	ledState := state.NewLEDState(6, "#000000")
+	ledState.SetSegment(0, state.Segment{ID: 0, Start: 0, Stop: 2, On: true})
+	ledState.SetSegment(1, state.Segment{ID: 1, Start: 2, Stop: 6, On: true, Rev: true})
+	s := NewServer(4048, ledState)
+
+	// Write LEDs 3 and 4, inside the reversed second segment
+	feedPacket(t, s, buildPacket(FlagPush, 1, 3*3, []byte{255, 0, 0, 0, 255, 0}))
+
+	red, green, black := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 0, 255}
+	for i, want := range []color.RGBA{black, black, black, green, red, black} {
+		if got := ledState.LEDs()[i]; got != want {
+			t.Errorf("LED %d = %v, want %v", i, got, want)
+		}
+	}
+
+	// The unreversed first segment is written as addressed
+	feedPacket(t, s, buildPacket(FlagPush, 2, 0, []byte{255, 0, 0}))
+	if got := ledState.LEDs()[0]; got != red {
+		t.Errorf("LED 0 = %v, want %v", got, red)
+	}
+}
+
+func TestPartialUpdateKeepsUntouchedPixels(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	green := color.RGBA{0, 255, 0, 255}
+	ledState := state.NewLEDState(6, "#000000")
+	s := NewServer(4048, ledState)
+
+	full := make([]byte, 0, 18)
+	for i := 0; i < 6; i++ {
+		full = append(full, 255, 0, 0)
+	}
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, full))
+	feedPacket(t, s, buildPacket(FlagPush, 2, 6, []byte{0, 255, 0, 0, 255, 0}))
+
+	for i, c := range ledState.LEDs() {
+		want := red
+		if i == 2 || i == 3 {
+			want = green
+		}
+		if c != want {
+			t.Errorf("LED %d = %v, want %v", i, c, want)
+		}
+	}
+}
+
+func TestLiveOverrideIgnoresPixels(t *testing.T) {
+	ledState := state.NewLEDState(1, "#000000")
+	s := NewServer(4048, ledState)
+	if err := ledState.SetLiveOverride(state.LiveOverrideAlways); err != nil {
+		t.Fatalf("SetLiveOverride failed: %v", err)
+	}
+
+	feedPacket(t, s, buildPacket(FlagPush, 1, 0, []byte{255, 0, 0}))
+	if got := ledState.LEDs()[0]; got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v, want unchanged while lor overrides live data", got)
+	}
+	if !ledState.IsLive() {
+		t.Error("the ignored stream should still keep the device live")
+	}
+
+	ledState.SetLiveOverride(state.LiveOverrideOff)
+	feedPacket(t, s, buildPacket(FlagPush, 2, 0, []byte{255, 0, 0}))
+	if got := ledState.LEDs()[0]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("LED 0 = %v, want red once lor is off", got)
+	}
+}
@@ -0,0 +1,42 @@
+package ddp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MaxStreamPacketSize is the largest packet accepted by ServeReader
+const MaxStreamPacketSize = 65535
+
+// ServeReader reads length-prefixed DDP packets from r and runs each through
+// the same parse, validate and process pipeline as UDP packets. Every packet
+// is preceded by its length as a 2 byte big-endian integer. It returns nil
+// at a clean end of input, or an error if a packet is truncated.
+func (s *Server) ServeReader(r io.Reader) error {
+	if s.jitter != nil {
+		go s.jitter.run(s.ctx, s.releaseFrame)
+	}
+
+	var prefix [2]byte
+	buf := make([]byte, MaxStreamPacketSize)
+	for {
+		if s.ctx.Err() != nil {
+			return nil
+		}
+
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read packet length: %v", err)
+		}
+		n := int(binary.BigEndian.Uint16(prefix[:]))
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return fmt.Errorf("failed to read %d byte packet: %v", n, err)
+		}
+
+		s.handlePacket(buf[:n], nil)
+	}
+}
@@ -0,0 +1,81 @@
+package ddp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+// frameStream length-prefixes each packet as ServeReader expects
+func frameStream(packets ...[]byte) *bytes.Buffer {
+	var buf bytes.Buffer
+	for _, p := range packets {
+		binary.Write(&buf, binary.BigEndian, uint16(len(p)))
+		buf.Write(p)
+	}
+	return &buf
+}
+
+func TestServeReader(t *testing.T) {
+	ledState := state.NewLEDState(4, "#000000")
+	s := NewServer(4048, ledState)
+
+	// Two packets filling the first and second halves of the strip
+	stream := frameStream(
+		buildPacket(0, 1, 0, []byte{255, 0, 0, 255, 0, 0}),
+		buildPacket(FlagPush, 2, 6, []byte{0, 0, 255, 0, 0, 255}),
+	)
+	if err := s.ServeReader(stream); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	for i, want := range []color.RGBA{red, red, blue, blue} {
+		if got := ledState.LEDs()[i]; got != want {
+			t.Errorf("LED %d = %v, want %v", i, got, want)
+		}
+	}
+	if frame, ok := s.LastFrame(); !ok || frame.Sequence != 2 {
+		t.Errorf("LastFrame = %+v, %v; want sequence 2", frame, ok)
+	}
+}
+
+func TestServeReaderJitterCommitsFrames(t *testing.T) {
+	ledState := state.NewLEDState(2, "#000000")
+	s := NewServer(4048, ledState)
+	s.SetJitterBuffer(10 * time.Millisecond)
+	defer s.Stop()
+
+	frames := make(chan []color.RGBA, 1)
+	ledState.SetOnFrame(func(leds []color.RGBA) { frames <- leds })
+
+	stream := frameStream(buildPacket(FlagPush, 1, 0, []byte{255, 0, 0, 0, 0, 255}))
+	if err := s.ServeReader(stream); err != nil {
+		t.Fatalf("ServeReader failed: %v", err)
+	}
+
+	// The frame is committed when the jitter buffer releases it
+	select {
+	case leds := <-frames:
+		want := []color.RGBA{{255, 0, 0, 255}, {0, 0, 255, 255}}
+		if leds[0] != want[0] || leds[1] != want[1] {
+			t.Errorf("committed frame = %v, want %v", leds, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("frame callback never fired for the released frame")
+	}
+}
+
+func TestServeReaderTruncated(t *testing.T) {
+	s := NewServer(4048, state.NewLEDState(4, "#000000"))
+
+	stream := frameStream(buildPacket(FlagPush, 1, 0, []byte{1, 2, 3}))
+	stream.Truncate(stream.Len() - 1)
+	if err := s.ServeReader(stream); err == nil {
+		t.Error("expected an error for a truncated packet")
+	}
+}
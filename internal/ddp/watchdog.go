@@ -0,0 +1,88 @@
+package ddp
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"time"
+)
+
+const (
+	// maxReadErrors is how many reads in a row may fail before the socket is
+	// considered dead and rebound
+	maxReadErrors = 10
+	// Rebinding waits between attempts, doubling from the first delay up to
+	// the last
+	minRebindBackoff = 100 * time.Millisecond
+	maxRebindBackoff = 5 * time.Second
+)
+
+// setConn records the socket Stop closes
+func (s *Server) setConn(conn *net.UDPConn) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	s.conn = conn
+}
+
+// supervise runs the read loop on conn and, if the loop dies for any reason
+// other than Stop, binds a new socket and starts again, backing off while
+// binding fails
+func (s *Server) supervise(conn *net.UDPConn) {
+	for {
+		err := s.readLoop(conn)
+		conn.Close()
+		if s.ctx.Err() != nil {
+			return // Normal shutdown
+		}
+		log.Printf("[DDP] Read loop died: %v; rebinding port %d", err, s.port)
+
+		backoff := minRebindBackoff
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			if conn, err = s.listen(); err == nil {
+				break
+			}
+			backoff = min(2*backoff, maxRebindBackoff)
+			log.Printf("[DDP] Rebind failed: %v; retrying in %v", err, backoff)
+		}
+
+		s.setConn(conn)
+		if s.ctx.Err() != nil {
+			conn.Close() // Stopped while rebinding
+			return
+		}
+		log.Printf("[DDP] Listening on UDP port %d again", s.port)
+	}
+}
+
+// readLoop processes packets from conn until Stop, returning nil, or until
+// the socket fails, returning why. A closed socket fails at once; other errors
+// are tolerated until maxReadErrors happen in a row.
+func (s *Server) readLoop(conn *net.UDPConn) error {
+	buf := make([]byte, 1500)
+	failures := 0
+	for {
+		n, remoteAddr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return err
+			}
+			failures++
+			log.Printf("[DDP] UDP read error: %v", err)
+			if failures >= maxReadErrors {
+				return fmt.Errorf("%d read errors in a row, last: %w", failures, err)
+			}
+			continue
+		}
+		failures = 0
+		s.handlePacket(buf[:n], remoteAddr)
+	}
+}
@@ -0,0 +1,68 @@
+package ddp
+
+import (
+	"image/color"
+	"net"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+func TestWatchdogRebindsAfterSocketError(t *testing.T) {
+	ledState := state.NewLEDState(1, "#000000")
+	s := NewServer(4065, ledState)
+	s.SetHost("127.0.0.1")
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer s.Stop()
+
+	// Break the socket out from under the read loop
+	s.connMu.Lock()
+	broken := s.conn
+	s.conn.Close()
+	s.connMu.Unlock()
+
+	client, err := net.Dial("udp", "127.0.0.1:4065")
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer client.Close()
+
+	// Packets are handled again once the port has been rebound
+	red := color.RGBA{255, 0, 0, 255}
+	deadline := time.Now().Add(3 * time.Second)
+	for seq := uint8(1); ledState.LEDs()[0] != red; seq++ {
+		if time.Now().After(deadline) {
+			t.Fatal("server did not recover after the socket failed")
+		}
+		client.Write(buildPacket(FlagPush, seq, 0, []byte{255, 0, 0}))
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.conn == broken {
+		t.Error("server still holds the closed socket")
+	}
+}
+
+func TestWatchdogStopsCleanly(t *testing.T) {
+	s := NewServer(4066, state.NewLEDState(1, "#000000"))
+	s.SetHost("127.0.0.1")
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	// Stopping is not a failure, so the port stays free
+	time.Sleep(2 * minRebindBackoff)
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 4066})
+	if err != nil {
+		t.Fatalf("port still bound after Stop: %v", err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,41 @@
+package gui
+
+import (
+	"image/color"
+	"time"
+)
+
+// ageSpan is how long after its last write an LED is shown fully stale
+const ageSpan = 5 * time.Second
+
+var (
+	freshColor = color.RGBA{255, 255, 255, 255}
+	staleColor = color.RGBA{0, 0, 255, 255}
+)
+
+// ageColors maps each LED's last write time to a color fading from white when
+// just written to blue once ageSpan has passed, to spot stuck pixels
+func ageColors(written []time.Time, now time.Time) []color.RGBA {
+	out := make([]color.RGBA, len(written))
+	for i, t := range written {
+		age := min(max(now.Sub(t), 0), ageSpan)
+		mix := func(a, b uint8) uint8 {
+			return uint8(int64(a) + (int64(b)-int64(a))*int64(age)/int64(ageSpan))
+		}
+		out[i] = color.RGBA{
+			R: mix(freshColor.R, staleColor.R),
+			G: mix(freshColor.G, staleColor.G),
+			B: mix(freshColor.B, staleColor.B),
+			A: 255,
+		}
+	}
+	return out
+}
+
+// SetAgeView switches the display to show how long ago each LED was written
+// instead of its color
+func (g *GUI) SetAgeView(on bool) {
+	g.renderMu.Lock()
+	defer g.renderMu.Unlock()
+	g.ageView = on
+}
@@ -22,21 +22,56 @@ type GUI struct {
 	app        fyne.App
 	window     fyne.Window
 	rectangles []*canvas.Rectangle
+	whiteDots  []*canvas.Circle // White channel overlay, one per rectangle
 	state      *state.LEDState
 	rows       int
 	cols       int
 	wiring     string
+	start      int // Index of the first state LED shown in this window
 	ctx        context.Context
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 	// Activity lights
 	jsonLightRect *canvas.Rectangle
 	ddpLightRect  *canvas.Rectangle
+	sourceText    *canvas.Text  // Address of the last DDP sender
+	hoverText     *canvas.Text  // Index and color of the LED under the pointer
+	senders       *sendersPanel // Active DDP sources, nil without controls
+	presets       *presetBar    // Preset buttons, nil without controls
 	flashTimers   map[*canvas.Rectangle]*time.Timer
 	timersMutex   sync.Mutex // Protect flashTimers map
+	// LED grid scaling
+	ledSize     float32
+	gridOffset  fyne.Position // Top-left corner of the centred grid
+	gridSize    fyne.Size     // Last size given to the LED grid
+	resizeTimer *time.Timer   // Debounces rescaling while the window is resized
+	ringScale   float32       // Ring radius as a fraction of the area, 0 for a grid
+	ringRadius  float32       // Distance of LED centres from the ring centre
+	resizeMu    sync.Mutex    // Protect grid geometry and resizeTimer
+	// Window sizing, in pixels at scale 1
+	minWindow fyne.Size         // Smallest window size
+	minSpacer *canvas.Rectangle // Invisible content holding the window at minWindow
+	chrome    fyne.Size         // Space taken by everything but the LED grid
+	// Display settings
+	showRaw      bool // Show stored colors instead of rendered output
+	ageView      bool // Show time since each LED was written instead of its color
+	whiteOverlay bool // Draw the separate white channel as a dot on each LED
+	rawCheck     *widget.Check
+	renderChunk  int        // Max LEDs updated per tick, 0 updates all
+	renderCursor int        // Next LED to update when rendering in chunks
+	renderMu     sync.Mutex // Protect display settings, renderChunk and renderCursor
+	stats        RenderStats
+	statsMu      sync.Mutex // Protect stats
 }
 
 func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string, controls bool) *GUI {
+	return newWindow(app, s, Matrix{Rows: rows, Cols: cols, Wiring: wiring, Name: name}, controls, true)
+}
+
+// newWindow builds the window for one matrix. Only a window that monitors
+// activity drains the state's activity channel and flashes its lights.
+func newWindow(app fyne.App, s *state.LEDState, m Matrix, controls, activity bool) *GUI {
+	rows, cols, wiring, name := m.Rows, m.Cols, m.Wiring, m.Name
 	totalLEDs := rows * cols
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -44,9 +79,11 @@ func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string
 		app:         app,
 		state:       s,
 		rectangles:  make([]*canvas.Rectangle, totalLEDs),
+		whiteDots:   make([]*canvas.Circle, totalLEDs),
 		rows:        rows,
 		cols:        cols,
 		wiring:      wiring,
+		start:       m.Start,
 		ctx:         ctx,
 		cancel:      cancel,
 		flashTimers: make(map[*canvas.Rectangle]*time.Timer),
@@ -104,31 +141,79 @@ func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string
 		ddpLabelContainer,
 	)
 
+	// Create the DDP source label, empty until a sender is live
+	gui.sourceText = canvas.NewText("", color.RGBA{100, 100, 100, 255})
+	gui.sourceText.TextSize = 10
+	gui.sourceText.Alignment = fyne.TextAlignLeading
+
+	sourceContainer := container.NewWithoutLayout(gui.sourceText)
+	gui.sourceText.Resize(fyne.NewSize(100, 12))
+	gui.sourceText.Move(fyne.NewPos(0, 0))
+	sourceContainer.Resize(fyne.NewSize(100, 12))
+
+	// Create the hover label, filled in while the pointer is over an LED
+	gui.hoverText = canvas.NewText("", color.RGBA{100, 100, 100, 255})
+	gui.hoverText.TextSize = 10
+	gui.hoverText.Alignment = fyne.TextAlignLeading
+
+	hoverContainer := container.NewWithoutLayout(gui.hoverText)
+	gui.hoverText.Resize(fyne.NewSize(120, 12))
+	gui.hoverText.Move(fyne.NewPos(0, 0))
+	hoverContainer.Resize(fyne.NewSize(120, 12))
+
+	// Create the raw/rendered toggle for checking decoding apart from brightness
+	gui.rawCheck = widget.NewCheck("Raw", func(on bool) {
+		gui.renderMu.Lock()
+		gui.showRaw = on
+		gui.renderMu.Unlock()
+	})
+
 	// Create the activity container as a horizontal status bar
 	activityContainer := container.NewHBox(
 		jsonContainer,
 		widget.NewLabel("    "), // Spacer between groups
 		ddpContainer,
+		sourceContainer,
+		hoverContainer,
+		gui.rawCheck,
 	)
+	// With controls, the status bar can also step through saved presets
+	if controls {
+		gui.presets = newPresetBar(gui)
+		activityContainer.Add(gui.presets.prev)
+		activityContainer.Add(gui.presets.label)
+		activityContainer.Add(gui.presets.next)
+	}
 
-	// Create a resizable grid container for LEDs
-	grid := container.NewGridWithColumns(cols)
+	// Create a grid container for LEDs that rescales them to fit the window
+	grid := container.New(&ledLayout{gui: gui})
 
 	// Add rectangles in row-major order for display (left-to-right, top-to-bottom)
-	ledSize := float32(16) // 16x16 pixel LEDs
+	ledSize := gui.scaled(defaultLEDSize)
+	gui.ledSize = ledSize
 	for i := 0; i < totalLEDs; i++ {
 		rect := canvas.NewRectangle(color.Black)
 		rect.Resize(fyne.NewSize(ledSize, ledSize))
 		gui.rectangles[i] = rect
 		grid.Add(rect)
 	}
+	// White dots sit above every LED and stay hidden unless enabled
+	for i := 0; i < totalLEDs; i++ {
+		dot := canvas.NewCircle(color.Transparent)
+		dot.Hide()
+		gui.whiteDots[i] = dot
+		grid.Add(dot)
+	}
 
-	// Calculate grid size and wrap in a resizable container
-	gridWidth := float32(cols) * ledSize
-	gridHeight := float32(rows) * ledSize
+	// Use a simple container that allows the grid to be resizable, with a
+	// transparent hover area on top for the pixel inspector
+	gridContainer := container.NewBorder(nil, nil, nil, nil, container.NewStack(grid, newHoverArea(gui)))
 
-	// Use a simple container that allows the grid to be resizable
-	gridContainer := container.NewBorder(nil, nil, nil, nil, grid)
+	// With controls, list the active DDP senders beside the grid
+	var sidePanel fyne.CanvasObject
+	if controls {
+		gui.senders, sidePanel = newSendersPanel()
+	}
 
 	// Create main container with activity lights at top, name below that, and LED grid at bottom
 	var mainContainer *fyne.Container
@@ -154,7 +239,7 @@ func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string
 			topSection,    // top
 			nil,           // bottom
 			nil,           // left
-			nil,           // right
+			sidePanel,     // right
 			gridContainer, // center (resizable)
 		)
 	} else {
@@ -162,28 +247,28 @@ func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string
 			activityContainer, // top
 			nil,               // bottom
 			nil,               // left
-			nil,               // right
+			sidePanel,         // right
 			gridContainer,     // center (resizable)
 		)
 	}
 
-	gui.window.SetContent(mainContainer)
+	// The window can't shrink below its content, so an invisible spacer
+	// behind everything enforces the minimum size
+	gui.minSpacer = canvas.NewRectangle(color.Transparent)
+	gui.window.SetContent(container.NewStack(gui.minSpacer, mainContainer))
 
-	// Calculate proper window size based on the actual grid content
+	// Size the window for the grid plus the activity lights and name
 	activityHeight := float32(35) // Height for activity lights area
 	nameHeight := float32(0)      // Height for name display
 	if name != "" {
 		nameHeight = 25 // Compact spacing: 5px gap + 20px name height
 	}
-	padding := float32(20) // Padding around the grid
-
-	// Set window size based on grid dimensions with some spacing
-	windowWidth := gridWidth + padding
-	if windowWidth < 120 { // Minimum width for activity lights
-		windowWidth = 120
+	gui.chrome = fyne.NewSize(0, activityHeight+nameHeight)
+	if sidePanel != nil {
+		gui.chrome.Width = sidePanel.MinSize().Width
 	}
-
-	gui.window.Resize(fyne.NewSize(windowWidth, gridHeight+activityHeight+nameHeight+padding))
+	gui.minWindow = defaultMinWindow
+	gui.fitWindow()
 
 	// Set up graceful shutdown on window close
 	gui.window.SetCloseIntercept(func() {
@@ -197,8 +282,10 @@ func NewApp(app fyne.App, s *state.LEDState, rows, cols int, wiring, name string
 	go gui.updateLoop()
 
 	// Start activity monitoring
-	gui.wg.Add(1)
-	go gui.monitorActivity()
+	if activity {
+		gui.wg.Add(1)
+		go gui.monitorActivity()
+	}
 
 	return gui
 }
@@ -217,6 +304,12 @@ func (g *GUI) stop() {
 	g.flashTimers = make(map[*canvas.Rectangle]*time.Timer)
 	g.timersMutex.Unlock()
 
+	g.resizeMu.Lock()
+	if g.resizeTimer != nil {
+		g.resizeTimer.Stop()
+	}
+	g.resizeMu.Unlock()
+
 	// Wait longer for any in-flight timer callbacks to complete
 	time.Sleep(200 * time.Millisecond)
 
@@ -256,11 +349,14 @@ func (g *GUI) updateLoop() {
 			return
 		case <-ticker.C:
 			g.updateDisplay()
+			g.updateSource()
+			g.updateSenders()
 		}
 	}
 }
 
-// updateDisplay updates all rectangles from the current LED state
+// updateDisplay recolours the rectangles whose LED changed since the last
+// update
 func (g *GUI) updateDisplay() {
 	// Check if context is cancelled before attempting GUI operations
 	select {
@@ -269,27 +365,110 @@ func (g *GUI) updateDisplay() {
 	default:
 	}
 
-	leds := g.state.LEDs()
+	// Show what the strip would display: power, brightness and segments
+	// applied, unless the raw stored colors were asked for
+	g.renderMu.Lock()
+	showRaw, ageView, whiteOverlay := g.showRaw, g.ageView, g.whiteOverlay
+	g.renderMu.Unlock()
+	var white []uint8
+	if (whiteOverlay || g.state.RGBW()) && !ageView {
+		white = window(g.state.White(), g.start, len(g.rectangles))
+	}
+	var leds []color.RGBA
+	if ageView {
+		leds = ageColors(window(g.state.LastWrites(), g.start, len(g.rectangles)), time.Now())
+	} else if showRaw {
+		leds = window(g.state.LEDs(), g.start, len(g.rectangles))
+	} else {
+		leds = window(g.state.RenderedLEDs(), g.start, len(g.rectangles))
+	}
+	if len(leds) == 0 {
+		return
+	}
+	start, end := g.nextRenderRange(len(leds))
 
 	// Use fyne.Do to avoid race conditions during shutdown
 	fyne.Do(func() {
-		for ledIndex, ledColor := range leds {
-			if ledIndex < len(leds) {
-				// Convert LED index to grid position based on wiring
-				row, col := g.ledIndexToGridPosition(ledIndex)
-
-				// Convert grid position to display rectangle index
-				displayIndex := g.gridPositionToDisplayIndex(row, col)
-
-				if displayIndex < len(g.rectangles) {
-					g.rectangles[displayIndex].FillColor = ledColor
-					g.rectangles[displayIndex].Refresh()
+		began := time.Now()
+		refreshed := 0
+		for i := start; i < end; i++ {
+			// Chunks may wrap past the end of the strip
+			ledIndex := i % len(leds)
+
+			// Convert LED index to grid position based on wiring
+			row, col := g.ledIndexToGridPosition(ledIndex)
+
+			// Convert grid position to display rectangle index
+			displayIndex := g.gridPositionToDisplayIndex(row, col)
+
+			if displayIndex < len(g.rectangles) {
+				// Only redraw LEDs whose colour changed
+				if rect := g.rectangles[displayIndex]; rect.FillColor != leds[ledIndex] {
+					rect.FillColor = leds[ledIndex]
+					rect.Refresh()
+					refreshed++
 				}
+				g.updateWhiteDot(g.whiteDots[displayIndex], white, ledIndex)
 			}
 		}
+		g.recordFrame(refreshed, time.Since(began))
 	}) // Non-blocking for regular updates
 }
 
+// SetShowRaw switches the display between the raw stored colors and the
+// rendered output with power, brightness and segments applied
+func (g *GUI) SetShowRaw(on bool) {
+	g.renderMu.Lock()
+	g.showRaw = on
+	g.renderMu.Unlock()
+	fyne.Do(func() {
+		g.rawCheck.SetChecked(on)
+	})
+}
+
+// SetRenderChunk limits how many LEDs are updated per tick, spreading a full
+// redraw of a large matrix over several ticks. Zero updates every LED each tick.
+func (g *GUI) SetRenderChunk(n int) {
+	if n < 0 {
+		n = 0
+	}
+	g.renderMu.Lock()
+	defer g.renderMu.Unlock()
+	g.renderChunk = n
+	g.renderCursor = 0
+}
+
+// nextRenderRange returns the LEDs to update this tick as [start, end), where
+// end may pass total and wraps around to the start of the strip
+func (g *GUI) nextRenderRange(total int) (start, end int) {
+	g.renderMu.Lock()
+	defer g.renderMu.Unlock()
+
+	if g.renderChunk == 0 || g.renderChunk >= total {
+		return 0, total
+	}
+	start = g.renderCursor % total
+	g.renderCursor = (start + g.renderChunk) % total
+	return start, start + g.renderChunk
+}
+
+// updateSource shows the last DDP sender, clearing it once live data times out
+func (g *GUI) updateSource() {
+	select {
+	case <-g.ctx.Done():
+		return
+	default:
+	}
+
+	source := g.state.LastSource()
+	fyne.Do(func() {
+		if g.sourceText.Text != source {
+			g.sourceText.Text = source
+			g.sourceText.Refresh()
+		}
+	})
+}
+
 // SetOnClose sets a custom close handler for the window
 func (g *GUI) SetOnClose(handler func()) {
 	g.window.SetCloseIntercept(func() {
@@ -298,6 +477,17 @@ func (g *GUI) SetOnClose(handler func()) {
 	})
 }
 
+// Show opens the window alongside one already running, such as a preview of
+// a second stream. Closing it stops its updates without quitting the app.
+func (g *GUI) Show() {
+	g.window.SetCloseIntercept(func() {
+		g.stop()
+		g.window.Close()
+	})
+	g.window.Show()
+	g.fitWindow()
+}
+
 // Run starts the GUI
 func (g *GUI) Run() {
 	fmt.Println("GUI: Showing window...")
@@ -316,7 +506,10 @@ func (g *GUI) Run() {
 		})
 	}()
 
-	g.window.ShowAndRun()
+	// The canvas scale is only known once the window is on a screen
+	g.window.Show()
+	g.fitWindow()
+	g.app.Run()
 	fmt.Println("GUI: Window closed")
 }
 
@@ -3,14 +3,18 @@ package gui
 import (
 	"context"
 	"image/color"
+	"math"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"wled-simulator/internal/ddp"
 	"wled-simulator/internal/state"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
 	"fyne.io/fyne/v2/test"
 )
 
@@ -222,3 +226,282 @@ func TestTimerCallbackRaceCondition(t *testing.T) {
 	// Restore original timers
 	gui.flashTimers = originalFlashTimers
 }
+
+func TestUpdateSource_ShowsLastSender(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(4, "#000000")
+	ledState.SetLiveTimeout(50 * time.Millisecond)
+	gui := NewApp(testApp, ledState, 2, 2, "row", "", false)
+	gui.stop() // Drive updates by hand
+	gui.ctx = context.Background()
+
+	// Simulate a DDP packet from a sender
+	ledState.SetLive()
+	ledState.SetLastSource("10.0.0.7")
+	gui.updateSource()
+
+	if got := gui.sourceText.Text; got != "10.0.0.7" {
+		t.Errorf("source label = %q, want %q", got, "10.0.0.7")
+	}
+
+	// Label clears once the live timeout has passed
+	time.Sleep(100 * time.Millisecond)
+	gui.updateSource()
+
+	if got := gui.sourceText.Text; got != "" {
+		t.Errorf("source label = %q after timeout, want empty", got)
+	}
+}
+
+func TestResize_RescalesLEDs(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(8, "#000000")
+	gui := NewApp(testApp, ledState, 2, 4, "row", "", false)
+	defer gui.stop()
+
+	// A burst of resizes only applies the last one once it settles
+	gui.onResize(fyne.NewSize(400, 200))
+	gui.onResize(fyne.NewSize(600, 300))
+	gui.onResize(fyne.NewSize(800, 400))
+	time.Sleep(3 * resizeDebounce)
+	gui.resizeMu.Lock()
+	defer gui.resizeMu.Unlock()
+
+	// 800/4 = 400/2 = 200px cells
+	if gui.ledSize != 200 {
+		t.Errorf("ledSize = %v, want 200", gui.ledSize)
+	}
+	want := fyne.NewSize(200-ledGap, 200-ledGap)
+	for i, rect := range gui.rectangles {
+		if rect.Size() != want {
+			t.Errorf("rectangle %d size = %v, want %v", i, rect.Size(), want)
+		}
+	}
+	if pos := gui.rectangles[5].Position(); pos != fyne.NewPos(200, 200) {
+		t.Errorf("rectangle 5 position = %v, want (200, 200)", pos)
+	}
+
+	// A wide area keeps LEDs square and centres the grid horizontally
+	gui.rescale(fyne.NewSize(1000, 100))
+	if gui.ledSize != 50 {
+		t.Errorf("ledSize = %v, want 50", gui.ledSize)
+	}
+	if pos := gui.rectangles[0].Position(); pos != fyne.NewPos(400, 0) {
+		t.Errorf("rectangle 0 position = %v, want (400, 0)", pos)
+	}
+}
+
+func TestHover_ShowsLEDIndexAndColor(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(4, "#000000")
+	gui := NewApp(testApp, ledState, 2, 2, "col", "", false)
+	defer gui.stop()
+
+	gui.resizeMu.Lock()
+	gui.rescale(fyne.NewSize(200, 200))
+	gui.resizeMu.Unlock()
+
+	// With column wiring, logical LED 1 is in the bottom-left cell
+	ledState.SetLED(1, color.RGBA{255, 128, 0, 255})
+	hover := newHoverArea(gui)
+	hover.MouseMoved(&desktop.MouseEvent{PointEvent: fyne.PointEvent{Position: fyne.NewPos(50, 150)}})
+
+	if got, want := gui.hoverText.Text, "LED 1: #FF8000"; got != want {
+		t.Errorf("hover label = %q, want %q", got, want)
+	}
+
+	hover.MouseOut()
+	if got := gui.hoverText.Text; got != "" {
+		t.Errorf("hover label = %q after mouse out, want empty", got)
+	}
+}
+
+func TestUpdateDisplay_ProgressiveRefresh(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(10, "#000000")
+	gui := NewApp(testApp, ledState, 2, 5, "row", "", false)
+	gui.stop() // Drive updates by hand
+	gui.ctx = context.Background()
+	gui.SetRenderChunk(4)
+
+	red := color.RGBA{255, 0, 0, 255}
+	for i := 0; i < 10; i++ {
+		ledState.SetLED(i, red)
+	}
+
+	// One tick only updates a chunk
+	gui.updateDisplay()
+	updated := 0
+	for _, rect := range gui.rectangles {
+		if rect.FillColor == red {
+			updated++
+		}
+	}
+	if updated != 4 {
+		t.Errorf("updated %d LEDs after one tick, want 4", updated)
+	}
+
+	// ceil(10/4) ticks cover the whole strip
+	gui.updateDisplay()
+	gui.updateDisplay()
+	for i, rect := range gui.rectangles {
+		if rect.FillColor != red {
+			t.Errorf("rectangle %d = %v after a full cycle, want %v", i, rect.FillColor, red)
+		}
+	}
+}
+
+func TestUpdateDisplay_ShowRaw(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(1, "#FF0000")
+	ledState.SetBrightness(128)
+	gui := NewApp(testApp, ledState, 1, 1, "row", "", false)
+	defer gui.stop()
+
+	gui.updateDisplay()
+	if got, want := gui.rectangles[0].FillColor, (color.RGBA{128, 0, 0, 255}); got != want {
+		t.Errorf("rendered color = %v, want %v", got, want)
+	}
+
+	gui.SetShowRaw(true)
+	if !gui.rawCheck.Checked {
+		t.Error("expected the Raw toggle to follow SetShowRaw")
+	}
+	gui.updateDisplay()
+	if got, want := gui.rectangles[0].FillColor, (color.RGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("raw color = %v, want %v", got, want)
+	}
+}
+
+func TestAgeColors_FadeToBlue(t *testing.T) {
+	now := time.Now()
+	colors := ageColors([]time.Time{now, now.Add(-ageSpan / 2), now.Add(-time.Minute)}, now)
+
+	if colors[0] != freshColor {
+		t.Errorf("fresh LED = %v, want %v", colors[0], freshColor)
+	}
+	if c := colors[1]; c.R != 128 || c.B != 255 {
+		t.Errorf("half-stale LED = %v, want halfway to blue", c)
+	}
+	if colors[2] != staleColor {
+		t.Errorf("stale LED = %v, want %v", colors[2], staleColor)
+	}
+}
+
+func TestUpdateSenders_ListsSources(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(4, "#000000")
+	gui := NewApp(testApp, ledState, 2, 2, "row", "", true)
+	defer gui.stop()
+
+	now := time.Now()
+	gui.SetSenders(func() []ddp.SenderStats {
+		return []ddp.SenderStats{
+			{Addr: "10.0.0.1", LastSeen: now, Packets: 10, Frames: 5, FPS: 30},
+			{Addr: "10.0.0.2", LastSeen: now, Packets: 4, Frames: 4, FPS: 2},
+		}
+	})
+	gui.updateSenders()
+
+	text := gui.senders.label.Text
+	for _, want := range []string{"10.0.0.1", "30.0 fps", "10.0.0.2", "4 frames"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("senders panel %q missing %q", text, want)
+		}
+	}
+
+	// Without controls there is no panel and SetSenders is a no-op
+	plain := NewApp(testApp, ledState, 2, 2, "row", "", false)
+	defer plain.stop()
+	plain.SetSenders(func() []ddp.SenderStats { return nil })
+	plain.updateSenders()
+}
+
+func TestUpdateDisplay_WhiteOverlay(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(2, "#FF0000")
+	ledState.SetWhite(0, 200)
+	gui := NewApp(testApp, ledState, 1, 2, "row", "", false)
+	defer gui.stop()
+
+	gui.updateDisplay()
+	if gui.whiteDots[0].Visible() {
+		t.Error("expected white dot hidden until the overlay is enabled")
+	}
+
+	gui.SetWhiteOverlay(true)
+	gui.updateDisplay()
+	if !gui.whiteDots[0].Visible() || gui.whiteDots[0].FillColor != whiteDotColor(200) {
+		t.Errorf("LED 0 dot = %v (visible %v), want %v", gui.whiteDots[0].FillColor, gui.whiteDots[0].Visible(), whiteDotColor(200))
+	}
+	if gui.whiteDots[1].Visible() {
+		t.Error("expected no dot on an LED without white")
+	}
+	if got, want := gui.rectangles[0].FillColor, (color.RGBA{255, 0, 0, 255}); got != want {
+		t.Errorf("LED 0 color = %v, want RGB unchanged %v", got, want)
+	}
+}
+
+func TestRingLayout_PlacesLEDsAroundCircle(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(8, "#000000")
+	gui := NewApp(testApp, ledState, 2, 4, "row", "", false)
+	defer gui.stop()
+
+	if err := gui.SetRingLayout(1.5); err == nil {
+		t.Error("expected an error for a radius above 1")
+	}
+	if err := gui.SetRingLayout(0.8); err != nil {
+		t.Fatalf("SetRingLayout failed: %v", err)
+	}
+	gui.resizeMu.Lock()
+	defer gui.resizeMu.Unlock()
+	gui.rescale(fyne.NewSize(400, 400))
+
+	// Every LED centre sits on the ring, one eighth of a turn apart
+	center := fyne.NewPos(200, 200)
+	for i := 0; i < 8; i++ {
+		rect := gui.rectangles[i]
+		c := rect.Position().AddXY(rect.Size().Width/2, rect.Size().Height/2)
+		dx, dy := float64(c.X-center.X), float64(c.Y-center.Y)
+		if r := math.Hypot(dx, dy); math.Abs(r-float64(gui.ringRadius)) > 0.01 {
+			t.Errorf("LED %d is %.2f from the centre, want %.2f", i, r, gui.ringRadius)
+		}
+		turn := math.Atan2(dx, -dy) / (2 * math.Pi)
+		if turn < 0 {
+			turn++
+		}
+		if want := float64(i) / 8; math.Abs(turn-want) > 0.001 {
+			t.Errorf("LED %d at %.3f of a turn, want %.3f", i, turn, want)
+		}
+	}
+	// The ring fits the area: LED 0 is at the top, inside the window
+	if top := gui.rectangles[0].Position().Y; top < 0 || top > 200-gui.ringRadius {
+		t.Errorf("LED 0 top = %v, want near the top of the ring", top)
+	}
+
+	// Hovering over an LED finds it by angle
+	c := gui.rectangles[2].Position().AddXY(gui.ledSize/4, gui.ledSize/4)
+	if got := gui.ringIndexAt(c); got != 2 {
+		t.Errorf("ringIndexAt over LED 2 = %d", got)
+	}
+	if got := gui.ringIndexAt(center); got != -1 {
+		t.Errorf("ringIndexAt centre = %d, want -1", got)
+	}
+}
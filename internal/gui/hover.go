@@ -0,0 +1,97 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// hoverArea is a transparent widget stacked over the LED grid that reports
+// the pointer position so the hovered LED can be shown in the status bar
+type hoverArea struct {
+	widget.BaseWidget
+	gui *GUI
+}
+
+var _ desktop.Hoverable = (*hoverArea)(nil)
+
+func newHoverArea(g *GUI) *hoverArea {
+	h := &hoverArea{gui: g}
+	h.ExtendBaseWidget(h)
+	return h
+}
+
+func (h *hoverArea) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(canvas.NewRectangle(color.Transparent))
+}
+
+func (h *hoverArea) MouseIn(ev *desktop.MouseEvent) {
+	h.gui.onHover(ev.Position)
+}
+
+func (h *hoverArea) MouseMoved(ev *desktop.MouseEvent) {
+	h.gui.onHover(ev.Position)
+}
+
+func (h *hoverArea) MouseOut() {
+	h.gui.setHoverText("")
+}
+
+// gridPositionToLEDIndex converts a grid position to a linear LED index based
+// on the wiring pattern, the inverse of ledIndexToGridPosition
+func (g *GUI) gridPositionToLEDIndex(row, col int) int {
+	if g.wiring == "col" {
+		return col*g.rows + row
+	}
+	return row*g.cols + col
+}
+
+// onHover shows the logical index and stored color of the LED under pos,
+// given relative to the LED grid
+func (g *GUI) onHover(pos fyne.Position) {
+	g.resizeMu.Lock()
+	cell, offset := g.ledSize, g.gridOffset
+	if g.ringScale > 0 {
+		index := g.ringIndexAt(pos)
+		g.resizeMu.Unlock()
+		g.showHoveredLED(index)
+		return
+	}
+	g.resizeMu.Unlock()
+
+	x, y := pos.X-offset.X, pos.Y-offset.Y
+	if cell <= 0 || x < 0 || y < 0 {
+		g.setHoverText("")
+		return
+	}
+	row, col := int(y/cell), int(x/cell)
+	if row >= g.rows || col >= g.cols {
+		g.setHoverText("")
+		return
+	}
+
+	g.showHoveredLED(g.gridPositionToLEDIndex(row, col))
+}
+
+// showHoveredLED shows the index and stored color of LED index within this
+// window, or clears the text when index is out of range
+func (g *GUI) showHoveredLED(index int) {
+	leds := window(g.state.LEDs(), g.start, len(g.rectangles))
+	if index < 0 || index >= len(leds) {
+		g.setHoverText("")
+		return
+	}
+	c := leds[index]
+	g.setHoverText(fmt.Sprintf("LED %d: #%02X%02X%02X", g.start+index, c.R, c.G, c.B))
+}
+
+func (g *GUI) setHoverText(text string) {
+	if g.hoverText.Text != text {
+		g.hoverText.Text = text
+		g.hoverText.Refresh()
+	}
+}
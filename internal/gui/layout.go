@@ -0,0 +1,110 @@
+package gui
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	defaultLEDSize = float32(16) // Initial LED size in pixels at scale 1
+	minLEDSize     = float32(2)  // Smallest LED the grid will shrink to
+	ledGap         = float32(2)  // Space between neighbouring LEDs
+	resizeDebounce = 50 * time.Millisecond
+)
+
+// ledLayout places the LED rectangles in a grid of square cells scaled to
+// fit the available space. Layout requests are debounced through the GUI.
+type ledLayout struct {
+	gui *GUI
+}
+
+func (l *ledLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	cell := l.gui.scaled(minLEDSize)
+	return fyne.NewSize(float32(l.gui.cols)*cell, float32(l.gui.rows)*cell)
+}
+
+func (l *ledLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	l.gui.onResize(size)
+}
+
+// onResize rescales the grid for the new size. The first layout is applied
+// immediately; later ones wait until resizing has settled.
+func (g *GUI) onResize(size fyne.Size) {
+	if size.IsZero() {
+		return // Not laid out yet
+	}
+
+	g.resizeMu.Lock()
+	defer g.resizeMu.Unlock()
+
+	first := g.gridSize.IsZero()
+	g.gridSize = size
+	if first {
+		g.rescale(size)
+		return
+	}
+
+	if g.resizeTimer != nil {
+		g.resizeTimer.Stop()
+	}
+	g.resizeTimer = time.AfterFunc(resizeDebounce, func() {
+		select {
+		case <-g.ctx.Done():
+			return
+		default:
+		}
+
+		fyne.Do(func() {
+			g.resizeMu.Lock()
+			defer g.resizeMu.Unlock()
+			g.rescale(g.gridSize)
+		})
+	})
+}
+
+// rescale sizes the LEDs to the largest square cell that fits and centres the
+// grid. Callers hold resizeMu.
+func (g *GUI) rescale(size fyne.Size) {
+	if g.rows == 0 || g.cols == 0 {
+		return
+	}
+	if g.ringScale > 0 {
+		g.rescaleRing(size)
+		return
+	}
+
+	cell := min(size.Width/float32(g.cols), size.Height/float32(g.rows))
+	cell = max(cell, g.scaled(minLEDSize))
+	g.ledSize = cell
+
+	gap := g.scaled(ledGap)
+	if cell <= 2*gap {
+		gap = 0
+	}
+
+	offsetX := (size.Width - cell*float32(g.cols)) / 2
+	offsetY := (size.Height - cell*float32(g.rows)) / 2
+	g.gridOffset = fyne.NewPos(offsetX, offsetY)
+	for i, rect := range g.rectangles {
+		if rect == nil {
+			continue // Grid still being built
+		}
+		// Display is always row-major (left-to-right, top-to-bottom)
+		row, col := i/g.cols, i%g.cols
+		g.placeLED(i, fyne.NewPos(offsetX+float32(col)*cell, offsetY+float32(row)*cell), cell-gap)
+	}
+}
+
+// placeLED moves display rectangle i and its white dot to pos with the given
+// side length. Callers hold resizeMu.
+func (g *GUI) placeLED(i int, pos fyne.Position, side float32) {
+	g.rectangles[i].Move(pos)
+	g.rectangles[i].Resize(fyne.NewSize(side, side))
+
+	// The white dot covers the middle third of the LED
+	if dot := g.whiteDots[i]; dot != nil {
+		dot.Move(pos.AddXY(side/3, side/3))
+		dot.Resize(fyne.NewSize(side/3, side/3))
+	}
+}
@@ -0,0 +1,81 @@
+package gui
+
+import (
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// Presets lists and applies saved presets for the preset buttons
+type Presets interface {
+	PresetNames() []string
+	ApplyPreset(name string) error
+}
+
+// presetBar steps through saved presets from the status bar
+type presetBar struct {
+	mu      sync.Mutex
+	source  Presets // nil until SetPresets is called
+	current string  // Name of the last preset applied, "" for none
+	label   *widget.Label
+	prev    *widget.Button
+	next    *widget.Button
+}
+
+func newPresetBar(g *GUI) *presetBar {
+	p := &presetBar{label: widget.NewLabel("No preset")}
+	p.prev = widget.NewButton("<", func() { g.stepPreset(-1) })
+	p.next = widget.NewButton(">", func() { g.stepPreset(1) })
+	return p
+}
+
+// SetPresets supplies the presets the previous and next buttons step
+// through, which are only present when the GUI was created with controls
+func (g *GUI) SetPresets(source Presets) {
+	if g.presets == nil {
+		return
+	}
+	g.presets.mu.Lock()
+	defer g.presets.mu.Unlock()
+	g.presets.source = source
+}
+
+// stepPreset applies the preset delta places from the current one, wrapping
+// at either end. Runs on the UI thread from the buttons.
+func (g *GUI) stepPreset(delta int) {
+	p := g.presets
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var names []string
+	if p.source != nil {
+		names = p.source.PresetNames()
+	}
+	if len(names) == 0 {
+		p.current = ""
+		p.label.SetText("No presets")
+		return
+	}
+
+	// Start from the first or last preset if none was applied or the
+	// current one has since been removed
+	next := 0
+	if delta < 0 {
+		next = len(names) - 1
+	}
+	for i, name := range names {
+		if name == p.current {
+			next = ((i+delta)%len(names) + len(names)) % len(names)
+			break
+		}
+	}
+
+	name := names[next]
+	if err := p.source.ApplyPreset(name); err != nil {
+		p.label.SetText(fmt.Sprintf("Preset %s: %v", name, err))
+		return
+	}
+	p.current = name
+	p.label.SetText(fmt.Sprintf("Preset %s (%d/%d)", name, next+1, len(names)))
+}
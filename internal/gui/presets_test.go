@@ -0,0 +1,86 @@
+package gui
+
+import (
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"wled-simulator/internal/api"
+	"wled-simulator/internal/state"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestPresetButtonsCycle(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(1, "#000000")
+	srv := api.NewServer(":0", ledState, 4048, api.Geometry{Rows: 1, Cols: 1, Wiring: "row"})
+	handler := srv.Handler()
+
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	save := func(name string, c color.RGBA) {
+		ledState.SetLED(0, c)
+		req := httptest.NewRequest(http.MethodPost, "/json/scene", strings.NewReader(`{"name":"`+name+`","save":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("saving preset %s returned %d", name, w.Code)
+		}
+	}
+	save("1", red)
+	save("2", blue)
+	ledState.SetLED(0, color.RGBA{A: 255})
+
+	gui := NewApp(testApp, ledState, 1, 1, "row", "", true)
+	defer gui.stop()
+	gui.SetPresets(srv)
+
+	steps := []struct {
+		prev  bool
+		want  color.RGBA
+		label string
+	}{
+		{want: red, label: "Preset 1 (1/2)"},
+		{want: blue, label: "Preset 2 (2/2)"},
+		{want: red, label: "Preset 1 (1/2)"}, // Wraps past the last
+		{prev: true, want: blue, label: "Preset 2 (2/2)"},
+	}
+	for i, step := range steps {
+		if step.prev {
+			test.Tap(gui.presets.prev)
+		} else {
+			test.Tap(gui.presets.next)
+		}
+		if got := ledState.LEDs()[0]; got != step.want {
+			t.Errorf("step %d: LED = %v, want %v", i, got, step.want)
+		}
+		if got := gui.presets.label.Text; got != step.label {
+			t.Errorf("step %d: label = %q, want %q", i, got, step.label)
+		}
+	}
+}
+
+func TestPresetButtonsWithoutPresets(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(1, "#000000")
+	gui := NewApp(testApp, ledState, 1, 1, "row", "", true)
+	defer gui.stop()
+
+	// Nothing supplied yet, then a server with nothing saved
+	test.Tap(gui.presets.next)
+	if got := gui.presets.label.Text; got != "No presets" {
+		t.Errorf("label = %q, want \"No presets\"", got)
+	}
+	gui.SetPresets(api.NewServer(":0", ledState, 4048, api.Geometry{Rows: 1, Cols: 1, Wiring: "row"}))
+	test.Tap(gui.presets.prev)
+	if got := gui.presets.label.Text; got != "No presets" {
+		t.Errorf("label = %q, want \"No presets\"", got)
+	}
+}
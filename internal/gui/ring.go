@@ -0,0 +1,90 @@
+package gui
+
+import (
+	"fmt"
+	"math"
+
+	"fyne.io/fyne/v2"
+)
+
+// SetRingLayout places the LEDs evenly around a circle in wiring order,
+// starting at the top and running clockwise, instead of in a grid. scale is
+// the ring's outer radius as a fraction of half the smaller window side, so
+// the ring grows and shrinks with the window. Zero returns to the grid.
+func (g *GUI) SetRingLayout(scale float32) error {
+	if scale < 0 || scale > 1 {
+		return fmt.Errorf("invalid ring radius %g. Must be between 0 and 1", scale)
+	}
+	g.resizeMu.Lock()
+	g.ringScale = scale
+	laidOut := !g.gridSize.IsZero()
+	g.resizeMu.Unlock()
+
+	if laidOut {
+		fyne.Do(func() {
+			g.resizeMu.Lock()
+			defer g.resizeMu.Unlock()
+			g.rescale(g.gridSize)
+		})
+	}
+	return nil
+}
+
+// rescaleRing sizes the LEDs to fit around the ring without overlapping and
+// spaces them evenly by angle. Callers hold resizeMu.
+func (g *GUI) rescaleRing(size fyne.Size) {
+	n := len(g.rectangles)
+	if n == 0 {
+		return
+	}
+
+	outer := min(size.Width, size.Height) / 2 * g.ringScale
+	cell := max(min(2*math.Pi*outer/float32(n), outer/2), g.scaled(minLEDSize))
+	g.ledSize = cell
+	g.ringRadius = outer - cell/2
+
+	gap := g.scaled(ledGap)
+	if cell <= 2*gap {
+		gap = 0
+	}
+	side := cell - gap
+
+	center := fyne.NewPos(size.Width/2, size.Height/2)
+	g.gridOffset = center
+	for i := 0; i < n; i++ {
+		if g.rectangles[i] == nil {
+			continue // Grid still being built
+		}
+		// Rectangles are stored in display order; find the LED each one shows
+		row, col := g.ledIndexToGridPosition(i)
+		display := g.gridPositionToDisplayIndex(row, col)
+		if display >= n {
+			continue
+		}
+		x, y := g.ringPoint(i, n)
+		g.placeLED(display, center.AddXY(x-side/2, y-side/2), side)
+	}
+}
+
+// ringPoint returns the offset from the ring centre of LED i of n. Callers
+// hold resizeMu.
+func (g *GUI) ringPoint(i, n int) (x, y float32) {
+	angle := 2*math.Pi*float64(i)/float64(n) - math.Pi/2
+	return g.ringRadius * float32(math.Cos(angle)), g.ringRadius * float32(math.Sin(angle))
+}
+
+// ringIndexAt returns the LED under pos, relative to the LED area, or -1 when
+// pos is off the ring. Callers hold resizeMu.
+func (g *GUI) ringIndexAt(pos fyne.Position) int {
+	n := len(g.rectangles)
+	dx, dy := float64(pos.X-g.gridOffset.X), float64(pos.Y-g.gridOffset.Y)
+	if n == 0 || math.Abs(math.Hypot(dx, dy)-float64(g.ringRadius)) > float64(g.ledSize)/2 {
+		return -1
+	}
+	// Angle clockwise from the top, in LED steps
+	turn := math.Atan2(dx, -dy) / (2 * math.Pi)
+	if turn < 0 {
+		turn++
+	}
+	return int(math.Round(turn*float64(n))) % n
+}
@@ -0,0 +1,51 @@
+package gui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+)
+
+// windowPadding is the space left around the grid in a new window
+const windowPadding = float32(20)
+
+// defaultMinWindow keeps the activity lights and a usable grid visible
+var defaultMinWindow = fyne.NewSize(200, 150)
+
+// uiScale returns the canvas scale factor, 2 on a typical HiDPI display
+func (g *GUI) uiScale() float32 {
+	if scale := g.window.Canvas().Scale(); scale > 0 {
+		return scale
+	}
+	return 1
+}
+
+// scaled converts a size in pixels at scale 1 to the current canvas scale
+func (g *GUI) scaled(v float32) float32 {
+	return v * g.uiScale()
+}
+
+// SetMinWindowSize sets the smallest size the window may be resized to, in
+// pixels at scale 1. Call it before Run.
+func (g *GUI) SetMinWindowSize(size fyne.Size) error {
+	if size.Width < 0 || size.Height < 0 {
+		return fmt.Errorf("invalid minimum window size %vx%v. Must not be negative", size.Width, size.Height)
+	}
+	g.minWindow = size
+	g.fitWindow()
+	return nil
+}
+
+// fitWindow sizes the window for LEDs of the default size at the current
+// canvas scale, and no smaller than the minimum window size
+func (g *GUI) fitWindow() {
+	minSize := fyne.NewSize(g.scaled(g.minWindow.Width), g.scaled(g.minWindow.Height))
+	g.minSpacer.SetMinSize(minSize)
+
+	led, padding := g.scaled(defaultLEDSize), g.scaled(windowPadding)
+	size := fyne.NewSize(
+		float32(g.cols)*led+padding+g.chrome.Width,
+		float32(g.rows)*led+padding+g.chrome.Height,
+	)
+	g.window.Resize(size.Max(minSize))
+}
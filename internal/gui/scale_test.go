@@ -0,0 +1,78 @@
+package gui
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+)
+
+// settledLEDSize waits for pending rescales and returns the LED cell and
+// rectangle sizes
+func settledLEDSize(g *GUI) (cell float32, rect fyne.Size) {
+	time.Sleep(3 * resizeDebounce)
+	g.resizeMu.Lock()
+	defer g.resizeMu.Unlock()
+	return g.ledSize, g.rectangles[0].Size()
+}
+
+func TestHiDPI_ScalesLEDs(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	// Large enough that the grid, not the minimum window size, sets the size
+	ledState := state.NewLEDState(20*30, "#000000")
+	gui := NewApp(testApp, ledState, 20, 30, "row", "", false)
+	defer gui.stop()
+
+	cell1, rect1 := settledLEDSize(gui)
+	if cell1 < defaultLEDSize || cell1 > defaultLEDSize+1 {
+		t.Fatalf("ledSize at 1x = %v, want about %v", cell1, defaultLEDSize)
+	}
+	if want := cell1 - ledGap; rect1.Width != want {
+		t.Errorf("rectangle width at 1x = %v, want %v", rect1.Width, want)
+	}
+
+	gui.window.Canvas().(test.WindowlessCanvas).SetScale(2)
+	gui.fitWindow()
+
+	cell2, rect2 := settledLEDSize(gui)
+	if math.Abs(float64(cell2-2*cell1)) > 1 {
+		t.Errorf("ledSize at 2x = %v, want about %v", cell2, 2*cell1)
+	}
+	// The gap between LEDs scales too
+	if want := cell2 - 2*ledGap; rect2.Width != want || rect2.Height != want {
+		t.Errorf("rectangle size at 2x = %v, want %vx%v", rect2, want, want)
+	}
+}
+
+func TestMinWindowSize(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(2, "#000000")
+	gui := NewApp(testApp, ledState, 1, 2, "row", "", false)
+	defer gui.stop()
+
+	if err := gui.SetMinWindowSize(fyne.NewSize(-1, 100)); err == nil {
+		t.Error("expected an error for a negative width")
+	}
+	if err := gui.SetMinWindowSize(fyne.NewSize(300, 250)); err != nil {
+		t.Fatalf("SetMinWindowSize failed: %v", err)
+	}
+	gui.window.Canvas().(test.WindowlessCanvas).SetScale(2)
+	gui.fitWindow()
+
+	// A tiny matrix still gets the minimum window, scaled
+	want := fyne.NewSize(600, 500)
+	if got := gui.window.Content().MinSize(); got.Width < want.Width || got.Height < want.Height {
+		t.Errorf("content min size = %v, want at least %v", got, want)
+	}
+	if got := gui.window.Canvas().Size(); got.Width < want.Width || got.Height < want.Height {
+		t.Errorf("window size = %v, want at least %v", got, want)
+	}
+}
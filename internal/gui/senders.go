@@ -0,0 +1,79 @@
+package gui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"wled-simulator/internal/ddp"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// sendersPanel lists the active DDP sources beside the grid
+type sendersPanel struct {
+	mu     sync.Mutex
+	source func() []ddp.SenderStats // nil until SetSenders is called
+	label  *widget.Label
+}
+
+func newSendersPanel() (*sendersPanel, fyne.CanvasObject) {
+	p := &sendersPanel{label: widget.NewLabel("No senders")}
+	p.label.TextStyle = fyne.TextStyle{Monospace: true}
+	title := widget.NewLabel("Senders")
+	title.TextStyle = fyne.TextStyle{Bold: true}
+	return p, container.NewVBox(title, p.label)
+}
+
+// formatSenders renders one line per sender with its rate, counts and age
+func formatSenders(senders []ddp.SenderStats, now time.Time) string {
+	if len(senders) == 0 {
+		return "No senders"
+	}
+	lines := make([]string, len(senders))
+	for i, s := range senders {
+		lines[i] = fmt.Sprintf("%-15s %5.1f fps %6d frames %6d pkts %4.1fs ago",
+			s.Addr, s.FPS, s.Frames, s.Packets, now.Sub(s.LastSeen).Seconds())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SetSenders supplies the per-source stats shown in the senders panel, which
+// is only present when the GUI was created with controls
+func (g *GUI) SetSenders(source func() []ddp.SenderStats) {
+	if g.senders == nil {
+		return
+	}
+	g.senders.mu.Lock()
+	defer g.senders.mu.Unlock()
+	g.senders.source = source
+}
+
+// updateSenders refreshes the senders panel from its source
+func (g *GUI) updateSenders() {
+	if g.senders == nil {
+		return
+	}
+	select {
+	case <-g.ctx.Done():
+		return
+	default:
+	}
+
+	g.senders.mu.Lock()
+	source := g.senders.source
+	g.senders.mu.Unlock()
+	if source == nil {
+		return
+	}
+
+	text := formatSenders(source(), time.Now())
+	fyne.Do(func() {
+		if g.senders.label.Text != text {
+			g.senders.label.SetText(text)
+		}
+	})
+}
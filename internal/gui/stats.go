@@ -0,0 +1,70 @@
+package gui
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RenderStats counts the work done applying LED state to the window, for
+// choosing render settings on large matrices
+type RenderStats struct {
+	Frames    int           // Display updates applied
+	Refreshed int           // Rectangles redrawn because their colour changed
+	Render    time.Duration // Time spent applying updates
+	Last      int           // Rectangles redrawn by the most recent update
+}
+
+// Since returns the work done between prev and r
+func (r RenderStats) Since(prev RenderStats) RenderStats {
+	return RenderStats{
+		Frames:    r.Frames - prev.Frames,
+		Refreshed: r.Refreshed - prev.Refreshed,
+		Render:    r.Render - prev.Render,
+		Last:      r.Last,
+	}
+}
+
+// RenderStats returns the totals since the window was created
+func (g *GUI) RenderStats() RenderStats {
+	g.statsMu.Lock()
+	defer g.statsMu.Unlock()
+	return g.stats
+}
+
+// recordFrame adds one display update that redrew refreshed rectangles
+func (g *GUI) recordFrame(refreshed int, took time.Duration) {
+	g.statsMu.Lock()
+	defer g.statsMu.Unlock()
+	g.stats.Frames++
+	g.stats.Refreshed += refreshed
+	g.stats.Render += took
+	g.stats.Last = refreshed
+}
+
+// LogRenderStats logs each window's rectangles refreshed per frame and mean
+// frame render time every interval until ctx is cancelled
+func (w Windows) LogRenderStats(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := make([]RenderStats, len(w))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for i, g := range w {
+			now := g.RenderStats()
+			d := now.Since(prev[i])
+			prev[i] = now
+			if d.Frames == 0 {
+				continue
+			}
+			log.Printf("GUI: %s: %d frames, %.1f rectangles refreshed per frame, %v average render",
+				g.window.Title(), d.Frames, float64(d.Refreshed)/float64(d.Frames),
+				d.Render/time.Duration(d.Frames))
+		}
+	}
+}
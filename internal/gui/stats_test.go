@@ -0,0 +1,81 @@
+package gui
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+
+	"fyne.io/fyne/v2/test"
+)
+
+func TestRenderStats_CountsChangedLEDs(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(8, "#000000")
+	gui := NewApp(testApp, ledState, 2, 4, "row", "", false)
+	gui.stop() // Drive updates by hand
+	gui.ctx = context.Background()
+	before := gui.RenderStats()
+
+	// Every LED changed
+	for i := 0; i < 8; i++ {
+		ledState.SetLED(i, color.RGBA{0, 255, 0, 255})
+	}
+	gui.updateDisplay()
+	if got := gui.RenderStats().Last; got != 8 {
+		t.Errorf("update after 8 changes refreshed %d rectangles, want 8", got)
+	}
+
+	// Nothing changed, so nothing is redrawn
+	gui.updateDisplay()
+	if got := gui.RenderStats().Last; got != 0 {
+		t.Errorf("unchanged update refreshed %d rectangles, want 0", got)
+	}
+
+	ledState.SetLED(1, color.RGBA{255, 0, 0, 255})
+	ledState.SetLED(6, color.RGBA{0, 0, 255, 255})
+	gui.updateDisplay()
+	stats := gui.RenderStats().Since(before)
+	if stats.Last != 2 {
+		t.Errorf("update after 2 changes refreshed %d rectangles, want 2", stats.Last)
+	}
+	if stats.Frames != 3 || stats.Refreshed != 10 {
+		t.Errorf("totals = %d frames, %d refreshed, want 3 and 10", stats.Frames, stats.Refreshed)
+	}
+	if stats.Render <= 0 {
+		t.Errorf("render time = %v, want it measured", stats.Render)
+	}
+
+	// Colours are still applied
+	if got := gui.rectangles[6].FillColor; got != (color.RGBA{0, 0, 255, 255}) {
+		t.Errorf("rectangle 6 = %v, want blue", got)
+	}
+}
+
+func TestLogRenderStats_StopsWithContext(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	gui := NewApp(testApp, state.NewLEDState(1, "#000000"), 1, 1, "row", "", false)
+	defer gui.stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Windows{gui}.LogRenderStats(ctx, time.Millisecond)
+		close(done)
+	}()
+	gui.updateDisplay()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("LogRenderStats did not stop when cancelled")
+	}
+}
@@ -0,0 +1,36 @@
+package gui
+
+import (
+	"image/color"
+
+	"fyne.io/fyne/v2/canvas"
+)
+
+// whiteDotColor is the overlay for a white channel value: white with the
+// value as its opacity
+func whiteDotColor(w uint8) color.NRGBA {
+	return color.NRGBA{R: 255, G: 255, B: 255, A: w}
+}
+
+// updateWhiteDot shows the white channel of LED i on dot, hiding it when the
+// overlay is off (white is nil) or the LED has no white. Must run on the
+// Fyne thread.
+func (g *GUI) updateWhiteDot(dot *canvas.Circle, white []uint8, i int) {
+	if i >= len(white) || white[i] == 0 {
+		if dot.Visible() {
+			dot.Hide()
+		}
+		return
+	}
+	dot.FillColor = whiteDotColor(white[i])
+	dot.Show()
+	dot.Refresh()
+}
+
+// SetWhiteOverlay draws each LED's separate white channel as a dot over its
+// RGB colour, for RGBW strips decoded with the white kept apart
+func (g *GUI) SetWhiteOverlay(on bool) {
+	g.renderMu.Lock()
+	defer g.renderMu.Unlock()
+	g.whiteOverlay = on
+}
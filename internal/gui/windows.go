@@ -0,0 +1,72 @@
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+
+	"wled-simulator/internal/state"
+)
+
+// Matrix describes one window: a Rows x Cols grid showing the state's LEDs
+// from index Start onwards
+type Matrix struct {
+	Name   string
+	Rows   int
+	Cols   int
+	Wiring string // "row" (row-major) or "col" (column-major)
+	Start  int
+}
+
+// Windows is a set of matrix windows sharing one Fyne app and LED state
+type Windows []*GUI
+
+// NewWindows creates a window per matrix, each with its own update loop.
+// Only the first window has the controls and flashes the activity lights.
+// Closing any window stops them all and quits the app.
+func NewWindows(app fyne.App, s *state.LEDState, matrices []Matrix, controls bool) Windows {
+	w := make(Windows, len(matrices))
+	for i, m := range matrices {
+		w[i] = newWindow(app, s, m, controls && i == 0, i == 0)
+	}
+	w.SetOnClose(app.Quit)
+	return w
+}
+
+// SetOnClose stops every window before calling handler when any is closed
+func (w Windows) SetOnClose(handler func()) {
+	for _, g := range w {
+		g.window.SetCloseIntercept(func() {
+			w.closeAll(handler)
+		})
+	}
+}
+
+// closeAll stops every window, then calls handler
+func (w Windows) closeAll(handler func()) {
+	w.stop()
+	handler()
+}
+
+// stop halts the update loops of all windows
+func (w Windows) stop() {
+	for _, g := range w {
+		g.stop()
+	}
+}
+
+// Run shows the other windows and then runs the first until the app quits
+func (w Windows) Run() {
+	for _, g := range w[1:] {
+		g.window.Show()
+		g.fitWindow()
+	}
+	w[0].Run()
+}
+
+// window returns the n entries of all starting at start, fewer if all ends
+// first
+func window[T any](all []T, start, n int) []T {
+	if start >= len(all) {
+		return nil
+	}
+	return all[start:min(start+n, len(all))]
+}
@@ -0,0 +1,58 @@
+package gui
+
+import (
+	"context"
+	"image/color"
+	"testing"
+
+	"fyne.io/fyne/v2/test"
+
+	"wled-simulator/internal/state"
+)
+
+func TestNewWindows_IndependentRanges(t *testing.T) {
+	testApp := test.NewApp()
+	defer testApp.Quit()
+
+	ledState := state.NewLEDState(8, "#000000")
+	windows := NewWindows(testApp, ledState, []Matrix{
+		{Name: "Panel 1", Rows: 2, Cols: 2, Wiring: "row", Start: 0},
+		{Name: "Panel 2", Rows: 2, Cols: 2, Wiring: "row", Start: 4},
+	}, false)
+	// Drive updates by hand, with fresh contexts for closeAll to cancel
+	for _, g := range windows {
+		g.stop()
+		g.ctx, g.cancel = context.WithCancel(context.Background())
+	}
+	defer windows.stop()
+
+	red := color.RGBA{255, 0, 0, 255}
+	ledState.SetLED(5, red)
+	for _, g := range windows {
+		g.updateDisplay()
+	}
+
+	black := color.RGBA{0, 0, 0, 255}
+	if got := windows[1].rectangles[1].FillColor; got != red {
+		t.Errorf("panel 2 LED 1 = %v, want %v", got, red)
+	}
+	for i, rect := range windows[0].rectangles {
+		if rect.FillColor != black {
+			t.Errorf("panel 1 LED %d = %v, want untouched black", i, rect.FillColor)
+		}
+	}
+
+	// Closing any window stops the update loops of all of them
+	closed := false
+	windows.closeAll(func() { closed = true })
+	if !closed {
+		t.Error("expected the close handler to run")
+	}
+	for i, g := range windows {
+		select {
+		case <-g.ctx.Done():
+		default:
+			t.Errorf("window %d still running after stop", i)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package imageframe
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+// defaultGIFDelay stands in for a zero frame delay, as browsers do
+const defaultGIFDelay = 100 * time.Millisecond
+
+// Animation is a looping sequence of images, each shown for its delay
+type Animation struct {
+	Frames []image.Image
+	Delays []time.Duration
+}
+
+// LoadAnimation reads a GIF, keeping its frame delays, or every PNG in a
+// directory in name order, shown fps times a second
+func LoadAnimation(path string, fps int) (*Animation, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read animation: %v", err)
+	}
+	if info.IsDir() {
+		return loadDir(path, fps)
+	}
+	return loadGIF(path)
+}
+
+// loadDir reads the PNGs in dir as equally spaced frames
+func loadDir(dir string, fps int) (*Animation, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid fps %d. Must be positive", fps)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read animation: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.EqualFold(filepath.Ext(e.Name()), ".png") {
+			names = append(names, e.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no PNG images in '%s'", dir)
+	}
+	sort.Strings(names)
+
+	a := &Animation{}
+	for _, name := range names {
+		img, err := Load(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		a.Frames = append(a.Frames, img)
+		a.Delays = append(a.Delays, time.Second/time.Duration(fps))
+	}
+	return a, nil
+}
+
+// loadGIF decodes every frame of a GIF. Frames may only cover part of the
+// picture, so each is drawn over the previous ones following its disposal
+// method to get the full image shown at that point.
+func loadGIF(path string) (*Animation, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read animation: %v", err)
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode GIF '%s': %v", path, err)
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	a := &Animation{}
+	for i, frame := range g.Image {
+		var saved *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			saved = image.NewRGBA(bounds)
+			draw.Draw(saved, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		shown := image.NewRGBA(bounds)
+		draw.Draw(shown, bounds, canvas, bounds.Min, draw.Src)
+		a.Frames = append(a.Frames, shown)
+
+		delay := defaultGIFDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		a.Delays = append(a.Delays, delay)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = saved
+		}
+	}
+	if len(a.Frames) == 0 {
+		return nil, fmt.Errorf("GIF '%s' has no frames", path)
+	}
+	return a, nil
+}
+
+// Play loops the animation onto every panel of st until ctx is cancelled or
+// a DDP sender takes over the LEDs
+func (a *Animation) Play(ctx context.Context, st *state.LEDState, rows, cols, panels int, wiring string) {
+	frames := make([][]color.RGBA, len(a.Frames))
+	for i, img := range a.Frames {
+		frames[i] = Tile(Frame(img, rows, cols, wiring), panels)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for i := 0; ; i = (i + 1) % len(frames) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if st.IsLive() {
+			return
+		}
+		st.SetLEDs(frames[i])
+		timer.Reset(a.Delays[i])
+	}
+}
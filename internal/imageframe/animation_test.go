@@ -0,0 +1,134 @@
+package imageframe
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wled-simulator/internal/state"
+)
+
+// writeGIF saves a 2x2 GIF with a solid red frame then a solid blue one,
+// each shown for delay hundredths of a second
+func writeGIF(t *testing.T, delay int) string {
+	t.Helper()
+	palette := color.Palette{red, blue}
+	g := &gif.GIF{}
+	for _, idx := range []uint8{0, 1} {
+		img := image.NewPaletted(image.Rect(0, 0, 2, 2), palette)
+		for i := range img.Pix {
+			img.Pix[i] = idx
+		}
+		g.Image = append(g.Image, img)
+		g.Delay = append(g.Delay, delay)
+	}
+
+	path := filepath.Join(t.TempDir(), "anim.gif")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, g); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// waitForColor polls until LED 0 of st is want
+func waitForColor(t *testing.T, st *state.LEDState, want color.RGBA) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if st.LEDs()[0] == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("LED 0 = %v, want %v", st.LEDs()[0], want)
+}
+
+func TestPlayGIFAdvancesFrames(t *testing.T) {
+	anim, err := LoadAnimation(writeGIF(t, 2), 0)
+	if err != nil {
+		t.Fatalf("LoadAnimation failed: %v", err)
+	}
+	if len(anim.Frames) != 2 || anim.Delays[0] != 20*time.Millisecond {
+		t.Fatalf("got %d frames with first delay %v, want 2 frames of 20ms", len(anim.Frames), anim.Delays[0])
+	}
+
+	st := state.NewLEDState(4, "#000000")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		anim.Play(ctx, st, 2, 2, 1, "row")
+		close(done)
+	}()
+
+	// Both frames show, then playback loops back to the first
+	waitForColor(t, st, red)
+	waitForColor(t, st, blue)
+	waitForColor(t, st, red)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Play did not stop when cancelled")
+	}
+}
+
+func TestPlayStopsWhenLive(t *testing.T) {
+	anim, err := LoadAnimation(writeGIF(t, 2), 0)
+	if err != nil {
+		t.Fatalf("LoadAnimation failed: %v", err)
+	}
+
+	st := state.NewLEDState(4, "#000000")
+	done := make(chan struct{})
+	go func() {
+		anim.Play(context.Background(), st, 2, 2, 1, "row")
+		close(done)
+	}()
+	waitForColor(t, st, red)
+
+	st.SetLive()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Play did not stop when DDP went live")
+	}
+}
+
+func TestLoadAnimationDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.png", "a.png"} {
+		if err := os.Rename(writeQuadrants(t), filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Other files are skipped
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	anim, err := LoadAnimation(dir, 4)
+	if err != nil {
+		t.Fatalf("LoadAnimation failed: %v", err)
+	}
+	if len(anim.Frames) != 2 {
+		t.Fatalf("got %d frames, want the 2 PNGs", len(anim.Frames))
+	}
+	if anim.Delays[0] != 250*time.Millisecond {
+		t.Errorf("delay = %v, want 250ms at 4 fps", anim.Delays[0])
+	}
+
+	if _, err := LoadAnimation(dir, 0); err == nil {
+		t.Error("expected an error for 0 fps")
+	}
+}
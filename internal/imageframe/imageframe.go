@@ -0,0 +1,58 @@
+// Package imageframe turns images into LED frames for a rows x cols matrix,
+// following the matrix wiring so the picture appears upright in the GUI.
+package imageframe
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/png" // Register the PNG decoder for Load
+	"os"
+)
+
+// Load decodes the image file at path
+func Load(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %v", err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image '%s': %v", path, err)
+	}
+	return img, nil
+}
+
+// Frame scales img to rows x cols with nearest-neighbour sampling and returns
+// the colours in LED order for the given wiring, "row" or "col"
+func Frame(img image.Image, rows, cols int, wiring string) []color.RGBA {
+	frame := make([]color.RGBA, rows*cols)
+	b := img.Bounds()
+	if b.Empty() {
+		return frame
+	}
+	for i := range frame {
+		row, col := i/cols, i%cols
+		if wiring == "col" {
+			row, col = i%rows, i/rows
+		}
+		// Sample the centre of each cell
+		x := b.Min.X + (2*col+1)*b.Dx()/(2*cols)
+		y := b.Min.Y + (2*row+1)*b.Dy()/(2*rows)
+		c := color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+		c.A = 255
+		frame[i] = c
+	}
+	return frame
+}
+
+// Tile repeats frame once per panel, so every panel shows the same picture
+func Tile(frame []color.RGBA, panels int) []color.RGBA {
+	out := make([]color.RGBA, 0, len(frame)*max(panels, 1))
+	for p := 0; p < max(panels, 1); p++ {
+		out = append(out, frame...)
+	}
+	return out
+}
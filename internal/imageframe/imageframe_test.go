@@ -0,0 +1,81 @@
+package imageframe
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var (
+	red   = color.RGBA{255, 0, 0, 255}
+	green = color.RGBA{0, 255, 0, 255}
+	blue  = color.RGBA{0, 0, 255, 255}
+	white = color.RGBA{255, 255, 255, 255}
+)
+
+// writeQuadrants saves a 2x2 PNG with red, green, blue and white pixels in
+// reading order
+func writeQuadrants(t *testing.T) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, red)
+	img.Set(1, 0, green)
+	img.Set(0, 1, blue)
+	img.Set(1, 1, white)
+
+	path := filepath.Join(t.TempDir(), "logo.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFrameCorners(t *testing.T) {
+	img, err := Load(writeQuadrants(t))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	tests := []struct {
+		wiring string
+		// LED indices of the top-left, top-right, bottom-left and bottom-right corners
+		corners [4]int
+	}{
+		{"row", [4]int{0, 3, 12, 15}},
+		{"col", [4]int{0, 12, 3, 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wiring, func(t *testing.T) {
+			frame := Frame(img, 4, 4, tt.wiring)
+			if len(frame) != 16 {
+				t.Fatalf("frame has %d LEDs, want 16", len(frame))
+			}
+			for i, want := range []color.RGBA{red, green, blue, white} {
+				if got := frame[tt.corners[i]]; got != want {
+					t.Errorf("corner %d (LED %d) = %v, want %v", i, tt.corners[i], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+	path := filepath.Join(t.TempDir(), "bad.png")
+	if err := os.WriteFile(path, []byte("not an image"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for an undecodable file")
+	}
+}
@@ -0,0 +1,87 @@
+// Package ledmap reads WLED ledmap files, which remap logical LED indices to
+// physical positions on the strip.
+package ledmap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// MaxMaps is how many numbered ledmaps WLED can switch between
+const MaxMaps = 10
+
+// file is the WLED ledmap.json layout
+type file struct {
+	Map []int `json:"map"`
+}
+
+// Load reads the map array from a WLED ledmap file such as
+// {"map":[0,1,2,5,4,3]}
+func Load(path string) ([]int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledmap: %v", err)
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse ledmap '%s': %v", path, err)
+	}
+	if f.Map == nil {
+		return nil, fmt.Errorf("ledmap '%s' has no \"map\" array", path)
+	}
+	return f.Map, nil
+}
+
+// LoadSet reads ledmap0.json through ledmap9.json from dir, keyed by their
+// number. Missing files are skipped, so the set may have gaps.
+func LoadSet(dir string) (map[int][]int, error) {
+	maps := make(map[int][]int)
+	for n := 0; n < MaxMaps; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("ledmap%d.json", n))
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		m, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		maps[n] = m
+	}
+	if len(maps) == 0 {
+		return nil, fmt.Errorf("no ledmap0.json to ledmap%d.json found in '%s'", MaxMaps-1, dir)
+	}
+	return maps, nil
+}
+
+// Check reports every way m fails to be a permutation of 0..count-1: a wrong
+// length, out of range entries, duplicates and indices that never appear.
+// It returns nil for a valid map.
+func Check(m []int, count int) []string {
+	var problems []string
+	if len(m) != count {
+		problems = append(problems, fmt.Sprintf("map has %d entries, want %d", len(m), count))
+	}
+
+	firstSeen := make(map[int]int, len(m))
+	for pos, idx := range m {
+		if idx < 0 || idx >= count {
+			problems = append(problems, fmt.Sprintf("entry %d: index %d out of range 0-%d", pos, idx, count-1))
+			continue
+		}
+		if prev, ok := firstSeen[idx]; ok {
+			problems = append(problems, fmt.Sprintf("entry %d: index %d already used by entry %d", pos, idx, prev))
+			continue
+		}
+		firstSeen[idx] = pos
+	}
+	for idx := 0; idx < count; idx++ {
+		if _, ok := firstSeen[idx]; !ok {
+			problems = append(problems, fmt.Sprintf("index %d is never mapped", idx))
+		}
+	}
+	return problems
+}
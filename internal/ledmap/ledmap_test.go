@@ -0,0 +1,81 @@
+package ledmap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMap(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "ledmap.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckValidMap(t *testing.T) {
+	m, err := Load(writeMap(t, `{"map":[0,1,2,5,4,3]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if problems := Check(m, 6); problems != nil {
+		t.Errorf("expected no problems, got %v", problems)
+	}
+	if problems := Check(m, 7); len(problems) != 2 {
+		t.Errorf("expected length and gap problems for count 7, got %v", problems)
+	}
+}
+
+func TestCheckDuplicate(t *testing.T) {
+	m, err := Load(writeMap(t, `{"map":[0,1,1,3]}`))
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	problems := Check(m, 4)
+	if len(problems) != 2 {
+		t.Fatalf("expected duplicate and gap problems, got %v", problems)
+	}
+	if !strings.Contains(problems[0], "index 1 already used by entry 1") {
+		t.Errorf("problems[0] = %q, want the duplicate reported", problems[0])
+	}
+	if !strings.Contains(problems[1], "index 2 is never mapped") {
+		t.Errorf("problems[1] = %q, want the gap reported", problems[1])
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	if _, err := Load(writeMap(t, `{"n":"no map"}`)); err == nil {
+		t.Error("expected an error for a file without a map")
+	}
+	if _, err := Load(writeMap(t, `not json`)); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestLoadSet(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"ledmap0.json": `{"map":[0,1]}`,
+		"ledmap2.json": `{"map":[1,0]}`,
+		"other.json":   `{"map":[0]}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	maps, err := LoadSet(dir)
+	if err != nil {
+		t.Fatalf("LoadSet failed: %v", err)
+	}
+	if len(maps) != 2 || maps[2][0] != 1 {
+		t.Errorf("maps = %v, want 0 and 2 loaded", maps)
+	}
+
+	if _, err := LoadSet(t.TempDir()); err == nil {
+		t.Error("expected an error for a directory without ledmaps")
+	}
+}
@@ -0,0 +1,139 @@
+// Package shm publishes the current frame to a file, typically under
+// /dev/shm, so that a companion process can read it without going through
+// the network.
+//
+// The file holds a fixed header followed by the pixels:
+//
+//	offset 0  uint32 little-endian  LED count
+//	offset 4  uint32 little-endian  write counter, see below
+//	offset 8  count * 4 bytes       R, G, B, A per LED
+//
+// The write counter is a seqlock: it is incremented to an odd value before
+// the pixels are written and to the next even value after, so the frame
+// number is half the counter. The writer does not wait for readers, so to
+// get a whole frame a reader reads the counter, the pixels and the counter
+// again, and retries if the counter was odd or changed in between, as
+// ReadFile does.
+package shm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image/color"
+	"os"
+	"sync"
+)
+
+// HeaderSize is the number of bytes before the pixel data
+const HeaderSize = 8
+
+// readAttempts is how many times ReadFile retries a frame that was being
+// written while it read
+const readAttempts = 100
+
+// Writer keeps a frame file up to date
+type Writer struct {
+	mu    sync.Mutex
+	file  *os.File
+	count int
+	seq   uint32
+	buf   []byte
+}
+
+// Create creates or truncates the file at path, sized for count LEDs
+func Create(path string, count int) (*Writer, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shared frame file: %v", err)
+	}
+	w := &Writer{
+		file:  f,
+		count: count,
+		buf:   make([]byte, HeaderSize+count*4),
+	}
+	binary.LittleEndian.PutUint32(w.buf[0:4], uint32(count))
+	if _, err := f.WriteAt(w.buf, 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write shared frame file: %v", err)
+	}
+	return w, nil
+}
+
+// Write publishes leds as the next frame. Extra LEDs are ignored and missing
+// ones are left black.
+func (w *Writer) Write(leds []color.RGBA) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Odd while the pixels are inconsistent
+	if err := w.bumpSeq(); err != nil {
+		return err
+	}
+	pixels := w.buf[HeaderSize:]
+	for i := 0; i < w.count; i++ {
+		c := color.RGBA{A: 255}
+		if i < len(leds) {
+			c = leds[i]
+		}
+		pixels[i*4], pixels[i*4+1], pixels[i*4+2], pixels[i*4+3] = c.R, c.G, c.B, c.A
+	}
+	if _, err := w.file.WriteAt(pixels, HeaderSize); err != nil {
+		return err
+	}
+	return w.bumpSeq()
+}
+
+// bumpSeq increments the write counter in the file. Callers hold mu.
+func (w *Writer) bumpSeq() error {
+	w.seq++
+	binary.LittleEndian.PutUint32(w.buf[4:8], w.seq)
+	_, err := w.file.WriteAt(w.buf[4:8], 4)
+	return err
+}
+
+// Close closes the file, leaving the last frame in place
+func (w *Writer) Close() error {
+	return w.file.Close()
+}
+
+// ReadFile reads a frame file written by Writer, returning its frame number
+// and pixels. A frame being written while it reads is read again.
+func ReadFile(path string) (uint32, []color.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	header := make([]byte, HeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return 0, nil, fmt.Errorf("shared frame file too short: %v", err)
+	}
+	count := int(binary.LittleEndian.Uint32(header[0:4]))
+	pixels := make([]byte, count*4)
+	for attempt := 0; attempt < readAttempts; attempt++ {
+		if _, err := f.ReadAt(header[4:8], 4); err != nil {
+			return 0, nil, err
+		}
+		before := binary.LittleEndian.Uint32(header[4:8])
+		if before%2 == 1 {
+			continue // Mid-write
+		}
+		if _, err := f.ReadAt(pixels, HeaderSize); err != nil {
+			return 0, nil, fmt.Errorf("shared frame file too short for %d LEDs: %v", count, err)
+		}
+		if _, err := f.ReadAt(header[4:8], 4); err != nil {
+			return 0, nil, err
+		}
+		if binary.LittleEndian.Uint32(header[4:8]) != before {
+			continue // Torn by a write
+		}
+
+		leds := make([]color.RGBA, count)
+		for i := range leds {
+			leds[i] = color.RGBA{R: pixels[i*4], G: pixels[i*4+1], B: pixels[i*4+2], A: pixels[i*4+3]}
+		}
+		return before / 2, leds, nil
+	}
+	return 0, nil, fmt.Errorf("shared frame file kept changing while being read")
+}
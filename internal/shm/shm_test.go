@@ -0,0 +1,88 @@
+package shm
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frame")
+	w, err := Create(path, 3)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer w.Close()
+
+	seq, leds, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if seq != 0 || len(leds) != 3 {
+		t.Errorf("new file has seq %d and %d LEDs, want 0 and 3", seq, len(leds))
+	}
+
+	frame := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	if err := w.Write(frame); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := w.Write(frame); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	seq, leds, err = ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if seq != 2 {
+		t.Errorf("seq = %d after two writes, want 2", seq)
+	}
+	for i := range frame {
+		if leds[i] != frame[i] {
+			t.Errorf("LED %d = %v, want %v", i, leds[i], frame[i])
+		}
+	}
+}
+
+func TestReadFileNeverTorn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frame")
+	w, err := Create(path, 256)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	defer w.Close()
+
+	// Every frame is a single colour, so a mix of two frames shows up as
+	// LEDs that differ
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		frame := make([]color.RGBA, 256)
+		for n := 0; n < 2000; n++ {
+			for i := range frame {
+				frame[i] = color.RGBA{R: uint8(n), G: uint8(n >> 8), A: 255}
+			}
+			if err := w.Write(frame); err != nil {
+				t.Errorf("Write failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		_, leds, err := ReadFile(path)
+		if err != nil {
+			continue // Writes outpaced the retries
+		}
+		for i, c := range leds {
+			if c != leds[0] {
+				t.Fatalf("torn frame: LED %d = %v, LED 0 = %v", i, c, leds[0])
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+)
+
+// Calibration maps each rendered channel value through a lookup table, so the
+// display can be matched to how the physical install looks
+type Calibration struct {
+	R [256]uint8
+	G [256]uint8
+	B [256]uint8
+}
+
+// calibrationFile is the on-disk format: three 256-entry arrays
+type calibrationFile struct {
+	R []int `json:"r"`
+	G []int `json:"g"`
+	B []int `json:"b"`
+}
+
+// IdentityCalibration returns a calibration that leaves colours unchanged
+func IdentityCalibration() *Calibration {
+	c := &Calibration{}
+	for i := 0; i < 256; i++ {
+		c.R[i], c.G[i], c.B[i] = uint8(i), uint8(i), uint8(i)
+	}
+	return c
+}
+
+// LoadCalibration reads a JSON file of the form {"r":[...],"g":[...],"b":[...]}
+// where each array has 256 output values (0-255) indexed by input value
+func LoadCalibration(path string) (*Calibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read calibration file: %v", err)
+	}
+	var f calibrationFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse calibration file '%s': %v", path, err)
+	}
+
+	c := &Calibration{}
+	for _, ch := range []struct {
+		name string
+		in   []int
+		out  *[256]uint8
+	}{
+		{"r", f.R, &c.R},
+		{"g", f.G, &c.G},
+		{"b", f.B, &c.B},
+	} {
+		if len(ch.in) != 256 {
+			return nil, fmt.Errorf("calibration channel '%s' has %d entries, must have 256", ch.name, len(ch.in))
+		}
+		for i, v := range ch.in {
+			if v < 0 || v > 255 {
+				return nil, fmt.Errorf("calibration channel '%s' entry %d is %d, must be 0-255", ch.name, i, v)
+			}
+			ch.out[i] = uint8(v)
+		}
+	}
+	return c, nil
+}
+
+// apply maps each channel of c through the lookup tables
+func (cal *Calibration) apply(c color.RGBA) color.RGBA {
+	return color.RGBA{R: cal.R[c.R], G: cal.G[c.G], B: cal.B[c.B], A: c.A}
+}
+
+// SetCalibration applies cal as the last step of rendering. Nil disables it.
+func (s *LEDState) SetCalibration(cal *Calibration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calibration = cal
+}
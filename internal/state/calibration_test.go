@@ -0,0 +1,67 @@
+package state
+
+import (
+	"encoding/json"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCalibration(t *testing.T, f calibrationFile) string {
+	t.Helper()
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCalibrationInverting(t *testing.T) {
+	invert := make([]int, 256)
+	for i := range invert {
+		invert[i] = 255 - i
+	}
+	path := writeCalibration(t, calibrationFile{R: invert, G: invert, B: invert})
+
+	cal, err := LoadCalibration(path)
+	if err != nil {
+		t.Fatalf("LoadCalibration failed: %v", err)
+	}
+
+	state := NewLEDState(1, "#FF4000")
+	state.SetCalibration(cal)
+	if got, want := state.RenderedLEDs()[0], (color.RGBA{0, 191, 255, 255}); got != want {
+		t.Errorf("rendered = %v, want %v", got, want)
+	}
+	// Stored colours are untouched
+	if got := state.LEDs()[0]; got != (color.RGBA{255, 64, 0, 255}) {
+		t.Errorf("stored = %v, want unchanged", got)
+	}
+
+	// The identity calibration changes nothing
+	state.SetCalibration(IdentityCalibration())
+	if got := state.RenderedLEDs()[0]; got != (color.RGBA{255, 64, 0, 255}) {
+		t.Errorf("rendered with identity = %v, want unchanged", got)
+	}
+}
+
+func TestLoadCalibrationErrors(t *testing.T) {
+	full := make([]int, 256)
+
+	if _, err := LoadCalibration(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing file")
+	}
+	if _, err := LoadCalibration(writeCalibration(t, calibrationFile{R: full, G: full, B: full[:10]})); err == nil {
+		t.Error("expected error for short channel")
+	}
+	bad := make([]int, 256)
+	bad[3] = 300
+	if _, err := LoadCalibration(writeCalibration(t, calibrationFile{R: full, G: bad, B: full})); err == nil {
+		t.Error("expected error for out of range value")
+	}
+}
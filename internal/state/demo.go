@@ -0,0 +1,52 @@
+package state
+
+import (
+	"context"
+	"image/color"
+	"time"
+)
+
+// RunDemo drives the LEDs and activity lights without real traffic, for
+// screenshots and demos. Every interval it advances a rainbow across the
+// strip and emits the next of JSON success, DDP success, JSON failure and
+// DDP failure. It runs until ctx is cancelled.
+func (s *LEDState) RunDemo(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for step := 0; ; step++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.RLock()
+		frame := make([]color.RGBA, len(s.leds))
+		s.mu.RUnlock()
+		for i := range frame {
+			frame[i] = wheel(uint8(i*256/max(len(frame), 1) + step*8))
+		}
+		s.SetLEDs(frame)
+
+		activity := ActivityJSON
+		if step%2 == 1 {
+			activity = ActivityDDP
+		}
+		s.ReportActivity(activity, step%4 < 2)
+	}
+}
+
+// wheel maps 0-255 onto a red, green, blue colour wheel
+func wheel(pos uint8) color.RGBA {
+	switch {
+	case pos < 85:
+		return color.RGBA{R: 255 - pos*3, G: pos * 3, A: 255}
+	case pos < 170:
+		pos -= 85
+		return color.RGBA{G: 255 - pos*3, B: pos * 3, A: 255}
+	default:
+		pos -= 170
+		return color.RGBA{R: pos * 3, B: 255 - pos*3, A: 255}
+	}
+}
@@ -0,0 +1,53 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunDemo(t *testing.T) {
+	state := NewLEDState(8, "#000000")
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		state.RunDemo(ctx, 5*time.Millisecond)
+		close(done)
+	}()
+
+	// The first four events cycle through both types and outcomes
+	want := []ActivityEvent{
+		{Type: ActivityJSON, Success: true},
+		{Type: ActivityDDP, Success: true},
+		{Type: ActivityJSON, Success: false},
+		{Type: ActivityDDP, Success: false},
+	}
+	for i, w := range want {
+		select {
+		case ev := <-state.ActivityChannel():
+			if ev.Type != w.Type || ev.Success != w.Success {
+				t.Errorf("event %d = %+v, want type %v success %v", i, ev, w.Type, w.Success)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	lit := false
+	for _, c := range state.LEDs() {
+		if c.R != 0 || c.G != 0 || c.B != 0 {
+			lit = true
+		}
+	}
+	if !lit {
+		t.Error("expected the demo to light the LEDs")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunDemo did not stop after cancel")
+	}
+}
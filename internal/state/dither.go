@@ -0,0 +1,41 @@
+package state
+
+import "image/color"
+
+// ditherThresholds is a 1-D ordered dither pattern: the 4-bit bit-reversal of
+// each position, so neighbouring LEDs get thresholds far apart and any 16 in
+// a row use each threshold once
+var ditherThresholds = [16]int{0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15}
+
+// SetDither turns ordered dithering of brightness scaling on or off. Instead
+// of truncating, each channel rounds up or down by its LED's position so the
+// average over neighbouring LEDs keeps the fraction, which smooths banding in
+// low brightness fades.
+func (s *LEDState) SetDither(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dither = on
+}
+
+// scaleLED scales LED i's colour by brightness (0-255), dithered when enabled.
+// Callers must hold the lock.
+func (s *LEDState) scaleLED(c color.RGBA, brightness, i int) color.RGBA {
+	if !s.dither {
+		return scaleColor(c, brightness)
+	}
+	return ditherColor(c, brightness, ditherThresholds[i%len(ditherThresholds)])
+}
+
+// ditherColor scales each channel by brightness, rounding up when the
+// remainder, in sixteenths, exceeds threshold (0-15)
+func ditherColor(c color.RGBA, brightness, threshold int) color.RGBA {
+	ch := func(v uint8) uint8 {
+		n := int(v) * brightness
+		out := n / 255
+		if (n%255)*16 > threshold*255 {
+			out++
+		}
+		return uint8(out)
+	}
+	return color.RGBA{R: ch(c.R), G: ch(c.G), B: ch(c.B), A: c.A}
+}
@@ -0,0 +1,44 @@
+package state
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestDitherKeepsAverage(t *testing.T) {
+	s := NewLEDState(32, "#030303")
+	s.SetBrightness(128)
+
+	// 3 * 128 / 255 is about 1.5, which truncates to 1 on every LED
+	for i, c := range s.RenderedLEDs() {
+		if c.R != 1 {
+			t.Fatalf("undithered LED %d = %d, want 1", i, c.R)
+		}
+	}
+
+	s.SetDither(true)
+	out := s.RenderedLEDs()
+	sum, seen := 0, map[uint8]bool{}
+	for i, c := range out {
+		if c.R != c.G || c.G != c.B {
+			t.Errorf("LED %d = %v, want equal channels for a grey", i, c)
+		}
+		seen[c.R] = true
+		sum += int(c.R)
+	}
+	if !seen[1] || !seen[2] || len(seen) != 2 {
+		t.Errorf("dithered values %v, want a mix of 1 and 2", seen)
+	}
+	if avg, want := float64(sum)/float64(len(out)), 3.0*128/255; avg < want-1.0/16 || avg > want+1.0/16 {
+		t.Errorf("average %.3f, want %.3f", avg, want)
+	}
+}
+
+func TestDitherExactValuesUnchanged(t *testing.T) {
+	for threshold := 0; threshold < 16; threshold++ {
+		c := color.RGBA{R: 255, G: 0, B: 100, A: 255}
+		if got := ditherColor(c, 255, threshold); got != c {
+			t.Errorf("threshold %d: full brightness %v, want %v", threshold, got, c)
+		}
+	}
+}
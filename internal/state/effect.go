@@ -0,0 +1,157 @@
+package state
+
+import (
+	"image/color"
+	"time"
+)
+
+// Effect ids follow WLED's numbering so clients select the same modes
+const (
+	FXSolid    = 0  // Stored colours, no effect
+	FXBlink    = 1  // Stored colours alternating with black
+	FXRainbow  = 9  // Palette scrolled along the segment over time
+	FXGradient = 46 // Palette stretched once across the segment
+)
+
+// rainbowStep is how long the rainbow effect takes to advance one palette step
+const rainbowStep = 20 * time.Millisecond
+
+// blinkHalf is how long the blink effect stays on, and then off
+const blinkHalf = 500 * time.Millisecond
+
+// Palette is a named colour gradient sampled by the effects
+type Palette struct {
+	Name  string
+	Stops []color.RGBA // Evenly spaced gradient stops; none means the colour wheel
+}
+
+// Gradient is a custom palette's colour stops, spaced evenly like a
+// Palette's
+type Gradient []color.RGBA
+
+// MaxGradientStops is the most stops a custom palette can have
+const MaxGradientStops = 16
+
+// Palettes are addressed by their index as a segment's pal
+var Palettes = []Palette{
+	{Name: "Default"},
+	{Name: "Sunset", Stops: []color.RGBA{
+		{120, 0, 0, 255}, {255, 80, 0, 255}, {255, 200, 40, 255}, {80, 0, 120, 255},
+	}},
+	{Name: "Ocean", Stops: []color.RGBA{
+		{0, 20, 80, 255}, {0, 120, 200, 255}, {0, 220, 180, 255}, {200, 255, 255, 255},
+	}},
+}
+
+// ValidEffect reports whether fx is an effect the simulator renders
+func ValidEffect(fx int) bool {
+	return fx == FXSolid || fx == FXBlink || fx == FXRainbow || fx == FXGradient
+}
+
+// fxFade cross-fades a segment from what it showed when its effect changed
+// into the new effect's output
+type fxFade struct {
+	from  []color.RGBA // Output at the switch, indexed from the segment start
+	start time.Time
+	dur   time.Duration
+}
+
+// palette returns the palette seg's effect samples: its custom gradient if it
+// has one, else Palettes[seg.Pal]
+func (seg Segment) palette() Palette {
+	if len(seg.CPal) > 0 {
+		return Palette{Name: "Custom", Stops: seg.CPal}
+	}
+	if ValidPalette(seg.Pal) {
+		return Palettes[seg.Pal]
+	}
+	return Palettes[0]
+}
+
+// ValidPalette reports whether pal indexes Palettes
+func ValidPalette(pal int) bool {
+	return pal >= 0 && pal < len(Palettes)
+}
+
+// sample returns the palette colour at pos, 0 being the first stop and 255
+// the last
+func (p Palette) sample(pos uint8) color.RGBA {
+	if len(p.Stops) == 0 {
+		return wheel(pos)
+	}
+	if len(p.Stops) == 1 {
+		return p.Stops[0]
+	}
+	span := len(p.Stops) - 1
+	scaled := int(pos) * span
+	i := min(scaled/255, span-1)
+	return lerpColor(p.Stops[i], p.Stops[i+1], scaled-i*255, 255)
+}
+
+// applyEffects overwrites the LEDs of segments running an effect with their
+// output at time now, blended with the previous effect while a switch is
+// fading. Callers hold mu.
+func (s *LEDState) applyEffects(out []color.RGBA, now time.Time) {
+	shift := int(now.UnixMilli() / rainbowStep.Milliseconds())
+	blinkOff := now.UnixMilli()/blinkHalf.Milliseconds()%2 == 1
+	for _, seg := range s.segments {
+		if seg.Len() <= 0 {
+			continue
+		}
+		pal := seg.palette()
+		n := seg.Len()
+		for i := seg.Start; i < seg.Stop && i < len(out); i++ {
+			switch seg.FX {
+			case FXBlink:
+				if blinkOff {
+					out[i] = color.RGBA{A: 255}
+				}
+			case FXRainbow:
+				out[i] = pal.sample(uint8((i-seg.Start)*256/n + shift))
+			case FXGradient:
+				out[i] = pal.sample(uint8((i - seg.Start) * 255 / max(n-1, 1)))
+			}
+		}
+		s.blendEffectFade(out, seg, now)
+	}
+}
+
+// startEffectFade begins cross-fading seg over the default transition when
+// its effect differs from old's. The fade starts from the output at this
+// moment, so switching again mid-fade continues from the blend on screen.
+// Callers hold mu.
+func (s *LEDState) startEffectFade(old, seg Segment) {
+	if old.FX == seg.FX || s.transition <= 0 {
+		return
+	}
+	now := time.Now()
+	current := make([]color.RGBA, len(s.leds))
+	copy(current, s.leds)
+	s.applyEffects(current, now)
+
+	if s.fxFades == nil {
+		s.fxFades = make(map[int]fxFade)
+	}
+	s.fxFades[seg.ID] = fxFade{
+		from:  append([]color.RGBA(nil), current[seg.Start:seg.Stop]...),
+		start: now,
+		dur:   s.transition,
+	}
+}
+
+// blendEffectFade mixes the output of seg in out with where its effect fade
+// started, if one is still running at now. Callers hold mu.
+func (s *LEDState) blendEffectFade(out []color.RGBA, seg Segment, now time.Time) {
+	f, ok := s.fxFades[seg.ID]
+	if !ok {
+		return
+	}
+	elapsed := now.Sub(f.start)
+	if elapsed >= f.dur {
+		return
+	}
+	elapsed = max(elapsed, 0)
+	for i := seg.Start; i < seg.Stop && i < len(out) && i-seg.Start < len(f.from); i++ {
+		out[i] = lerpColor(f.from[i-seg.Start], out[i], int(elapsed), int(f.dur))
+	}
+}
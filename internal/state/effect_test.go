@@ -0,0 +1,134 @@
+package state
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestPaletteSample(t *testing.T) {
+	p := Palette{Stops: []color.RGBA{{0, 0, 0, 255}, {200, 100, 0, 255}, {0, 0, 250, 255}}}
+	tests := []struct {
+		pos  uint8
+		want color.RGBA
+	}{
+		{0, color.RGBA{0, 0, 0, 255}},
+		{127, color.RGBA{199, 99, 0, 255}},
+		{255, color.RGBA{0, 0, 250, 255}},
+	}
+	for _, tt := range tests {
+		if got := p.sample(tt.pos); got != tt.want {
+			t.Errorf("sample(%d) = %v, want %v", tt.pos, got, tt.want)
+		}
+	}
+	// Without stops the palette is the colour wheel
+	if got := Palettes[0].sample(0); got != wheel(0) {
+		t.Errorf("Default sample(0) = %v, want %v", got, wheel(0))
+	}
+}
+
+func TestRainbowEffectScrolls(t *testing.T) {
+	state := NewLEDState(8, "#000000")
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 8, On: true, FX: FXRainbow, Pal: 2})
+
+	now := time.UnixMilli(0)
+	a := make([]color.RGBA, 8)
+	b := make([]color.RGBA, 8)
+	state.applyEffects(a, now)
+	state.applyEffects(b, now.Add(32*rainbowStep))
+
+	// 32 steps is one LED's worth of palette on an 8 LED segment
+	for i := 0; i < 7; i++ {
+		if b[i] != a[i+1] {
+			t.Errorf("after scrolling, LED %d = %v, want %v", i, b[i], a[i+1])
+		}
+	}
+}
+
+// blinkOffAfter returns the first time at or after t when the blink effect
+// is off
+func blinkOffAfter(t time.Time) time.Time {
+	for t.UnixMilli()/blinkHalf.Milliseconds()%2 == 0 {
+		t = t.Add(time.Millisecond)
+	}
+	return t
+}
+
+func TestEffectCrossfade(t *testing.T) {
+	state := NewLEDState(4, "#FF0000")
+	state.SetTransition(time.Second)
+	seg := state.Segments()[0]
+	seg.FX = FXBlink
+	if err := state.SetSegment(0, seg); err != nil {
+		t.Fatalf("SetSegment failed: %v", err)
+	}
+
+	state.mu.RLock()
+	fade := state.fxFades[seg.ID]
+	state.mu.RUnlock()
+	if fade.dur != time.Second {
+		t.Fatalf("fade duration = %v, want the 1s transition", fade.dur)
+	}
+
+	render := func(now time.Time) color.RGBA {
+		out := state.LEDs()
+		state.mu.RLock()
+		defer state.mu.RUnlock()
+		state.applyEffects(out, now)
+		return out[0]
+	}
+
+	// While blink is off, the output fades from Solid's red towards black
+	var last uint8 = 255
+	for _, at := range []time.Duration{100 * time.Millisecond, 400 * time.Millisecond} {
+		now := blinkOffAfter(fade.start.Add(at)) // At most 900ms in
+		elapsed := now.Sub(fade.start)
+		got := render(now)
+		want := uint8(255 - 255*elapsed/fade.dur)
+		if got.R < want-1 || got.R > want+1 || got.G != 0 || got.B != 0 {
+			t.Errorf("%v into the fade: LED 0 = %v, want R about %d", elapsed, got, want)
+		}
+		if got.R == 0 || got.R == 255 || got.R > last {
+			t.Errorf("%v into the fade: R = %d, want a blend darker than %d", elapsed, got.R, last)
+		}
+		last = got.R
+	}
+
+	// Once the transition ends, blink is fully off
+	if got := render(blinkOffAfter(fade.start.Add(fade.dur))); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("after the fade: LED 0 = %v, want black", got)
+	}
+}
+
+func TestEffectCrossfadeSwitchMidway(t *testing.T) {
+	state := NewLEDState(4, "#FF0000")
+	state.SetTransition(time.Second)
+	seg := state.Segments()[0]
+	seg.FX = FXGradient
+	state.SetSegment(0, seg)
+	time.Sleep(50 * time.Millisecond)
+
+	state.mu.RLock()
+	first := state.fxFades[seg.ID]
+	state.mu.RUnlock()
+
+	seg.FX = FXSolid
+	state.SetSegment(0, seg)
+	state.mu.RLock()
+	second := state.fxFades[seg.ID]
+	state.mu.RUnlock()
+
+	// The second fade starts from the blend that was showing, not from
+	// either effect alone
+	elapsed := second.start.Sub(first.start)
+	if elapsed >= first.dur {
+		t.Fatalf("second switch came %v after the first, after its fade ended", elapsed)
+	}
+	for i, from := range second.from {
+		gradient := Palettes[0].sample(uint8(i * 255 / 3))
+		want := lerpColor(first.from[i], gradient, int(elapsed), int(first.dur))
+		if from != want {
+			t.Errorf("LED %d fades from %v, want the mid-fade blend %v", i, from, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package state
+
+// Wrap returns i modulo n in the range [0, n), so positions past the end
+// come round to the start and negative ones count back from the end. n must
+// be positive.
+func Wrap(i, n int) int {
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// Grid addresses LEDs wired as a rows x cols matrix by column x and row y,
+// for effects that draw in two dimensions
+type Grid struct {
+	Rows     int
+	Cols     int
+	Wiring   string // "row" (row-major) or "col" (column-major)
+	Toroidal bool   // Wrap coordinates at the edges instead of dropping them
+}
+
+// Index returns the LED index at x, y. Coordinates outside the matrix wrap
+// round to the opposite edge on a toroidal grid and give -1 otherwise.
+func (g Grid) Index(x, y int) int {
+	if g.Rows <= 0 || g.Cols <= 0 {
+		return -1
+	}
+	if g.Toroidal {
+		x, y = Wrap(x, g.Cols), Wrap(y, g.Rows)
+	} else if x < 0 || x >= g.Cols || y < 0 || y >= g.Rows {
+		return -1
+	}
+	if g.Wiring == "col" {
+		return x*g.Rows + y
+	}
+	return y*g.Cols + x
+}
+
+// Position returns the column and row of LED i, the inverse of Index for
+// indices within the matrix. An empty grid puts every LED at 0, 0.
+func (g Grid) Position(i int) (x, y int) {
+	if g.Rows <= 0 || g.Cols <= 0 {
+		return 0, 0
+	}
+	if g.Wiring == "col" {
+		return i / g.Rows, i % g.Rows
+	}
+	return i % g.Cols, i / g.Cols
+}
@@ -0,0 +1,71 @@
+package state
+
+import "testing"
+
+func TestWrap(t *testing.T) {
+	for _, tt := range []struct{ i, n, want int }{
+		{0, 5, 0},
+		{4, 5, 4},
+		{5, 5, 0},
+		{12, 5, 2},
+		{-1, 5, 4},
+		{-5, 5, 0},
+		{-11, 5, 4},
+	} {
+		if got := Wrap(tt.i, tt.n); got != tt.want {
+			t.Errorf("Wrap(%d, %d) = %d, want %d", tt.i, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestGridIndex(t *testing.T) {
+	row := Grid{Rows: 2, Cols: 3, Wiring: "row", Toroidal: true}
+	col := Grid{Rows: 2, Cols: 3, Wiring: "col", Toroidal: true}
+	for _, tt := range []struct {
+		name     string
+		x, y     int
+		row, col int
+	}{
+		{"inside", 1, 1, 4, 3},
+		{"past right edge", 3, 0, 0, 0},
+		{"left of left edge", -1, 0, 2, 4},
+		{"below bottom edge", 2, 2, 2, 4},
+		{"above top edge", 0, -1, 3, 1},
+		{"both negative", -4, -3, 5, 5},
+		{"far over", 7, 5, 4, 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := row.Index(tt.x, tt.y); got != tt.row {
+				t.Errorf("row wiring Index(%d, %d) = %d, want %d", tt.x, tt.y, got, tt.row)
+			}
+			if got := col.Index(tt.x, tt.y); got != tt.col {
+				t.Errorf("col wiring Index(%d, %d) = %d, want %d", tt.x, tt.y, got, tt.col)
+			}
+		})
+	}
+}
+
+func TestGridIndexBounded(t *testing.T) {
+	g := Grid{Rows: 2, Cols: 3, Wiring: "row"}
+	for _, xy := range [][2]int{{-1, 0}, {3, 0}, {0, -1}, {0, 2}} {
+		if got := g.Index(xy[0], xy[1]); got != -1 {
+			t.Errorf("Index(%d, %d) = %d, want -1 off the edge", xy[0], xy[1], got)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		if x, y := g.Position(i); g.Index(x, y) != i {
+			t.Errorf("Position(%d) = %d, %d does not index back", i, x, y)
+		}
+	}
+}
+
+func TestGridEmpty(t *testing.T) {
+	for _, g := range []Grid{{Wiring: "row"}, {Wiring: "col"}, {Rows: 4, Wiring: "row"}, {Cols: 4, Wiring: "col"}} {
+		if x, y := g.Position(3); x != 0 || y != 0 {
+			t.Errorf("%+v Position(3) = %d, %d, want 0, 0", g, x, y)
+		}
+		if got := g.Index(0, 0); got != -1 {
+			t.Errorf("%+v Index(0, 0) = %d, want -1", g, got)
+		}
+	}
+}
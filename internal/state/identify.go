@@ -0,0 +1,54 @@
+package state
+
+import "time"
+
+// Identify defaults: three white flashes, each on and off for a quarter second
+const (
+	IdentifyFlashes = 3
+	IdentifyPeriod  = 250 * time.Millisecond
+)
+
+// Identify blinks the whole output white flashes times, on and off for
+// period each, so a user can tell which simulator this is. The flash is laid
+// over the rendered output rather than written to the LEDs, so the previous
+// frame reappears afterwards along with anything written meanwhile. Each
+// flash is also reported as JSON activity to blink the GUI light. A new call
+// replaces one in progress.
+func (s *LEDState) Identify(flashes int, period time.Duration) {
+	s.mu.Lock()
+	s.identifyGen++
+	gen := s.identifyGen
+	s.identifying = false
+	s.mu.Unlock()
+
+	go func() {
+		for i := 0; i < 2*flashes; i++ {
+			on := i%2 == 0
+			s.mu.Lock()
+			if s.identifyGen != gen {
+				// Superseded by a newer identify
+				s.mu.Unlock()
+				return
+			}
+			s.identifying = on
+			s.mu.Unlock()
+			if on {
+				s.ReportActivity(ActivityJSON, true)
+			}
+			time.Sleep(period)
+		}
+
+		s.mu.Lock()
+		if s.identifyGen == gen {
+			s.identifying = false
+		}
+		s.mu.Unlock()
+	}()
+}
+
+// Identifying reports whether an identify flash is currently lit
+func (s *LEDState) Identifying() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.identifying
+}
@@ -0,0 +1,63 @@
+package state
+
+import (
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIdentifyFlashesAndRestores(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	state := NewLEDState(3, "#FF0000")
+
+	// waitFor polls cond rather than sleeping for a fixed part of a period,
+	// which a busy machine can overshoot
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	rendered := func(c color.RGBA) func() bool {
+		return func() bool {
+			return !slices.ContainsFunc(state.RenderedLEDs(), func(led color.RGBA) bool { return led != c })
+		}
+	}
+
+	const period = 40 * time.Millisecond
+	state.Identify(2, period)
+
+	waitFor("the first flash", rendered(white))
+	if got := state.LEDs()[0]; got != red {
+		t.Errorf("stored LED 0 = %v during flash, want saved red", got)
+	}
+
+	// Dark between flashes shows the original frame, then the second flash
+	waitFor("the dark between flashes", rendered(red))
+	waitFor("the second flash", state.Identifying)
+
+	// One flash is reported as activity per blink
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-state.ActivityChannel():
+			if ev.Type != ActivityJSON || !ev.Success {
+				t.Errorf("activity %d = %+v, want JSON success", i, ev)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected 2 activity events, got %d", i)
+		}
+	}
+
+	waitFor("identify to finish", func() bool { return !state.Identifying() })
+	for i, c := range state.RenderedLEDs() {
+		if c != red {
+			t.Errorf("rendered LED %d = %v after identify, want original red", i, c)
+		}
+	}
+}
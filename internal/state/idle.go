@@ -0,0 +1,76 @@
+package state
+
+import (
+	"context"
+	"image/color"
+	"time"
+)
+
+// BlackoutOnIdle blanks the rendered output whenever live data stops, until
+// it resumes or the LEDs, power or brightness are changed another way, such
+// as through the JSON API. If wipe is set the stored LED buffer is cleared as
+// well.
+// It polls the live state every interval until ctx is cancelled.
+func (s *LEDState) BlackoutOnIdle(ctx context.Context, interval time.Duration, wipe bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	wasLive := s.IsLive()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			live := s.IsLive()
+			if wasLive && !live {
+				s.mu.Lock()
+				s.blackout = true
+				if wipe {
+					for i := range s.leds {
+						s.leds[i] = color.RGBA{A: 255}
+					}
+				}
+				s.mu.Unlock()
+			}
+			wasLive = live
+		}
+	}
+}
+
+// Blackout reports whether the output is blanked because live data stopped
+func (s *LEDState) Blackout() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blackout
+}
+
+// WaitForIdle blocks until no DDP packet has arrived for timeout and returns
+// true, or returns false if ctx is cancelled first. The window starts when
+// WaitForIdle is called and restarts on every packet, including heartbeats
+// and queries without pixel data.
+func (s *LEDState) WaitForIdle(ctx context.Context, timeout time.Duration) bool {
+	start := time.Now()
+	interval := min(timeout/10, 100*time.Millisecond)
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			s.mu.RLock()
+			last := s.lastPacketTime
+			s.mu.RUnlock()
+			if last.Before(start) {
+				last = start
+			}
+			if time.Since(last) >= timeout {
+				return true
+			}
+		}
+	}
+}
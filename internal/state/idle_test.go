@@ -0,0 +1,145 @@
+package state
+
+import (
+	"context"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestBlackoutOnIdle(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	black := color.RGBA{0, 0, 0, 255}
+
+	tests := []struct {
+		name       string
+		wipe       bool
+		wantStored color.RGBA
+	}{
+		{"keep stored buffer", false, red},
+		{"wipe stored buffer", true, black},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := NewLEDState(4, "#FF0000")
+			state.SetLiveTimeout(50 * time.Millisecond)
+			state.SetLive()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go state.BlackoutOnIdle(ctx, 10*time.Millisecond, tt.wipe)
+
+			// Still live: display shows the frame
+			if got := state.RenderedLEDs()[0]; got != red {
+				t.Fatalf("rendered while live = %v, want %v", got, red)
+			}
+
+			// Stop feeding and wait past the timeout
+			time.Sleep(150 * time.Millisecond)
+			if !state.Blackout() {
+				t.Fatal("expected blackout after live timeout")
+			}
+			if got := state.RenderedLEDs()[0]; got != black {
+				t.Errorf("rendered after timeout = %v, want %v", got, black)
+			}
+			if got := state.LEDs()[0]; got != tt.wantStored {
+				t.Errorf("stored after timeout = %v, want %v", got, tt.wantStored)
+			}
+
+			// Live data resuming clears the blackout
+			state.SetLive()
+			if state.Blackout() {
+				t.Error("expected blackout to clear when live data resumes")
+			}
+		})
+	}
+}
+
+func TestBlackoutClearedByStateChange(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	for name, change := range map[string]func(s *LEDState){
+		"colour":     func(s *LEDState) { s.FillRange(0, 4, red) },
+		"frame":      func(s *LEDState) { s.SetLEDs([]color.RGBA{red, red, red, red}) },
+		"brightness": func(s *LEDState) { s.SetBrightness(200) },
+		"transition": func(s *LEDState) { s.TransitionBrightness(200, 0) },
+		"power":      func(s *LEDState) { s.SetPower(true) },
+		"preset":     func(s *LEDState) { s.Restore(s.Snapshot()) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			state := NewLEDState(4, "#FF0000")
+			state.mu.Lock()
+			state.blackout = true // As left by BlackoutOnIdle once live data stopped
+			state.mu.Unlock()
+
+			change(state)
+			if state.Blackout() {
+				t.Fatal("blackout still set after a state change")
+			}
+			if got := state.RenderedLEDs()[0]; got.R == 0 {
+				t.Errorf("rendered = %v, want the red frame shown again", got)
+			}
+		})
+	}
+}
+
+func TestWaitForIdle(t *testing.T) {
+	state := NewLEDState(1, "#000000")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Keep packets arriving for a while; the window restarts on each one
+	go func() {
+		for i := 0; i < 5; i++ {
+			state.SetLive()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	start := time.Now()
+	if !state.WaitForIdle(ctx, 50*time.Millisecond) {
+		t.Fatal("expected WaitForIdle to report idle")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("idle fired after %v, before traffic stopped", elapsed)
+	}
+}
+
+func TestWaitForIdleCancelled(t *testing.T) {
+	state := NewLEDState(1, "#000000")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if state.WaitForIdle(ctx, time.Hour) {
+		t.Error("expected WaitForIdle to return false when cancelled")
+	}
+}
+
+func TestWaitForIdleHeartbeats(t *testing.T) {
+	state := NewLEDState(1, "#000000")
+	state.SetLiveDebounce(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A sender only sending heartbeats is still present
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				state.SetConnected()
+			}
+		}
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer waitCancel()
+	if state.WaitForIdle(waitCtx, 100*time.Millisecond) {
+		t.Error("reported idle while heartbeats were arriving")
+	}
+}
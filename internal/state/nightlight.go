@@ -0,0 +1,97 @@
+package state
+
+import "time"
+
+// NightlightMode selects how the nightlight reaches its target brightness
+type NightlightMode int
+
+const (
+	NightlightInstant   NightlightMode = iota // Hold brightness, then switch at the end
+	NightlightFade                            // Fade brightness to the target
+	NightlightColorFade                       // Simulated as a brightness fade
+	NightlightSunrise                         // Simulated as a brightness fade
+)
+
+// Nightlight is the nightlight timer configuration. Remaining is reported by
+// Nightlight and ignored by SetNightlight.
+type Nightlight struct {
+	On        bool
+	Duration  time.Duration
+	Mode      NightlightMode
+	Target    int // Brightness at the end; 0 turns the output off
+	Remaining time.Duration
+}
+
+// DefaultNightlight matches WLED's defaults: a one hour fade to off
+var DefaultNightlight = Nightlight{Duration: time.Hour, Mode: NightlightFade}
+
+// Nightlight returns the nightlight configuration and, while it is running,
+// the time left
+func (s *LEDState) Nightlight() Nightlight {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	nl := s.nightlight
+	if nl.On {
+		nl.Remaining = max(time.Until(s.nlDeadline), 0)
+	}
+	return nl
+}
+
+// SetNightlight updates the nightlight configuration. Turning it on starts
+// the timer from the current brightness; turning it off cancels the timer and
+// leaves brightness where it is. Changing settings while on does not restart it.
+func (s *LEDState) SetNightlight(nl Nightlight) {
+	nl.Target = max(0, min(nl.Target, 255))
+	nl.Remaining = 0
+
+	s.mu.Lock()
+	wasOn := s.nightlight.On
+	s.nightlight = nl
+	if !nl.On {
+		s.nlGen++
+		s.mu.Unlock()
+		return
+	}
+	if wasOn {
+		s.mu.Unlock()
+		return
+	}
+	s.nlGen++
+	gen := s.nlGen
+	from := s.brightness
+	start := time.Now()
+	s.nlDeadline = start.Add(nl.Duration)
+	s.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(transitionStep)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			elapsed := time.Since(start)
+
+			s.mu.Lock()
+			if s.nlGen != gen {
+				// Cancelled or restarted
+				s.mu.Unlock()
+				return
+			}
+			if elapsed >= nl.Duration {
+				s.nightlight.On = false
+				if nl.Target == 0 {
+					// Switch off, keeping the brightness to turn back on at
+					s.power = false
+					s.brightness = from
+				} else {
+					s.brightness = nl.Target
+				}
+				s.mu.Unlock()
+				return
+			}
+			if nl.Mode != NightlightInstant {
+				s.brightness = from + int(float64(nl.Target-from)*float64(elapsed)/float64(nl.Duration))
+			}
+			s.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNightlightInstant(t *testing.T) {
+	s := NewLEDState(1, "#FFFFFF")
+	s.SetBrightness(200)
+	s.SetNightlight(Nightlight{On: true, Duration: 100 * time.Millisecond, Mode: NightlightInstant})
+
+	// No fade before the deadline
+	for i := 0; i < 4; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if b := s.Brightness(); b != 200 || !s.Power() {
+			t.Fatalf("brightness = %d, power = %v before the deadline, want 200 and on", b, s.Power())
+		}
+	}
+	if rem := s.Nightlight().Remaining; rem <= 0 || rem > 100*time.Millisecond {
+		t.Errorf("remaining = %v, want within the duration", rem)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if s.Power() {
+		t.Error("expected power off after the deadline")
+	}
+	if s.Nightlight().On {
+		t.Error("expected the nightlight to end")
+	}
+	if b := s.Brightness(); b != 200 {
+		t.Errorf("brightness = %d after switching off, want 200 kept for power on", b)
+	}
+}
+
+func TestNightlightFade(t *testing.T) {
+	s := NewLEDState(1, "#FFFFFF")
+	s.SetBrightness(200)
+	s.SetNightlight(Nightlight{On: true, Duration: 200 * time.Millisecond, Mode: NightlightFade, Target: 100})
+
+	time.Sleep(100 * time.Millisecond)
+	if b := s.Brightness(); b <= 100 || b >= 200 {
+		t.Errorf("brightness = %d halfway, want between 100 and 200", b)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if b := s.Brightness(); b != 100 || !s.Power() {
+		t.Errorf("brightness = %d, power = %v at the end, want 100 and on", b, s.Power())
+	}
+}
+
+func TestNightlightCancel(t *testing.T) {
+	s := NewLEDState(1, "#FFFFFF")
+	nl := Nightlight{On: true, Duration: 50 * time.Millisecond, Mode: NightlightInstant}
+	s.SetNightlight(nl)
+	nl.On = false
+	s.SetNightlight(nl)
+
+	time.Sleep(100 * time.Millisecond)
+	if !s.Power() {
+		t.Error("expected a cancelled nightlight to leave the power on")
+	}
+}
@@ -0,0 +1,41 @@
+package state
+
+import "image/color"
+
+// SetOverlay sets LED i's overlay colour, blended over the rendered output
+// by its alpha: 0 leaves the LED as it is and 255 replaces it. The RGB values
+// are taken as not premultiplied, so {255, 0, 0, 128} is half-strength red.
+// The overlay is separate from the stored colours, so DDP and API writes do
+// not disturb it.
+func (s *LEDState) SetOverlay(i int, c color.RGBA) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i < 0 || i >= len(s.leds) {
+		return
+	}
+	if s.overlay == nil {
+		s.overlay = make([]color.RGBA, len(s.leds))
+	}
+	s.overlay[i] = c
+}
+
+// ClearOverlay removes the overlay from every LED
+func (s *LEDState) ClearOverlay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overlay = nil
+}
+
+// blendOverlay mixes the overlay into out. Callers must hold the lock.
+func (s *LEDState) blendOverlay(out []color.RGBA) {
+	for i, o := range s.overlay {
+		if o.A == 0 || i >= len(out) {
+			continue
+		}
+		a := int(o.A)
+		mix := func(base, top uint8) uint8 {
+			return uint8((int(base)*(255-a) + int(top)*a) / 255)
+		}
+		out[i] = color.RGBA{R: mix(out[i].R, o.R), G: mix(out[i].G, o.G), B: mix(out[i].B, o.B), A: 255}
+	}
+}
@@ -0,0 +1,33 @@
+package state
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestOverlayBlendsOverBase(t *testing.T) {
+	s := NewLEDState(3, "#00FF00")
+
+	s.SetOverlay(0, color.RGBA{R: 255, A: 128})
+	s.SetOverlay(1, color.RGBA{R: 255, A: 255})
+	s.SetOverlay(7, color.RGBA{R: 255, A: 255}) // Out of range, ignored
+
+	out := s.RenderedLEDs()
+	if want := (color.RGBA{R: 128, G: 127, B: 0, A: 255}); out[0] != want {
+		t.Errorf("half red over green = %v, want %v", out[0], want)
+	}
+	if want := (color.RGBA{R: 255, A: 255}); out[1] != want {
+		t.Errorf("opaque red over green = %v, want %v", out[1], want)
+	}
+	if want := (color.RGBA{G: 255, A: 255}); out[2] != want {
+		t.Errorf("LED without overlay = %v, want %v", out[2], want)
+	}
+	if stored := s.LEDs()[0]; stored != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("stored LED = %v, want green left unchanged", stored)
+	}
+
+	s.ClearOverlay()
+	if out := s.RenderedLEDs(); out[0] != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("after ClearOverlay LED 0 = %v, want green", out[0])
+	}
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+)
+
+// Live override modes, WLED's lor. They decide whether DDP data or colours
+// set any other way are shown while a stream is live.
+const (
+	LiveOverrideOff      = 0 // Live data wins; other colour changes are ignored while live
+	LiveOverrideUntilEnd = 1 // Live data is ignored until the current stream ends
+	LiveOverrideAlways   = 2 // Live data is ignored until the override is turned off
+)
+
+// SetLiveOverride sets the lor mode
+func (s *LEDState) SetLiveOverride(lor int) error {
+	if lor < LiveOverrideOff || lor > LiveOverrideAlways {
+		return fmt.Errorf("invalid live override %d. Must be 0-2", lor)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.liveOverride = lor
+	return nil
+}
+
+// LiveOverride returns the lor mode in effect. LiveOverrideUntilEnd reads
+// as off once the stream it was set during has ended.
+func (s *LEDState) LiveOverride() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.liveOverride == LiveOverrideUntilEnd && !s.live() {
+		return LiveOverrideOff
+	}
+	return s.liveOverride
+}
+
+// FillRange sets LEDs start to stop-1 to c, unless a live stream currently
+// has precedence, in which case nothing is written and it returns false. The
+// check and the write happen together so a packet can't slip in between.
+func (s *LEDState) FillRange(start, stop int, c color.RGBA) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.live() && s.liveOverride == LiveOverrideOff {
+		return false
+	}
+	s.blackout = false
+	now := time.Now()
+	for i := max(start, 0); i < stop && i < len(s.leds); i++ {
+		if !s.frozen(i) {
+			s.leds[i] = c
+			s.written[i] = now
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestFillRangeYieldsToLive(t *testing.T) {
+	s := NewLEDState(4, "#000000")
+	red, green := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}
+
+	if !s.FillRange(0, 4, red) || s.LEDs()[3] != red {
+		t.Fatal("FillRange should write while not live")
+	}
+
+	s.SetLive()
+	if s.FillRange(0, 4, green) {
+		t.Error("FillRange wrote while live with lor 0")
+	}
+	if got := s.LEDs()[0]; got != red {
+		t.Errorf("LED 0 = %v, want %v left by the live stream", got, red)
+	}
+
+	if err := s.SetLiveOverride(LiveOverrideAlways); err != nil {
+		t.Fatalf("SetLiveOverride failed: %v", err)
+	}
+	if !s.FillRange(1, 3, green) {
+		t.Error("FillRange skipped while live with lor 2")
+	}
+	if got := s.LEDs(); got[0] != red || got[1] != green || got[2] != green || got[3] != red {
+		t.Errorf("LEDs = %v, want only 1 and 2 green", got)
+	}
+
+	if err := s.SetLiveOverride(3); err == nil {
+		t.Error("expected an error for lor 3")
+	}
+}
+
+func TestLiveOverrideUntilStreamEnds(t *testing.T) {
+	s := NewLEDState(1, "#000000")
+	s.SetLiveTimeout(20 * time.Millisecond)
+	s.SetLive()
+	s.SetLiveOverride(LiveOverrideUntilEnd)
+	if got := s.LiveOverride(); got != LiveOverrideUntilEnd {
+		t.Fatalf("lor = %d during the stream, want 1", got)
+	}
+
+	// Once the stream times out the override no longer applies, including
+	// to the next stream
+	time.Sleep(30 * time.Millisecond)
+	if got := s.LiveOverride(); got != LiveOverrideOff {
+		t.Errorf("lor = %d after the stream ended, want 0", got)
+	}
+	s.SetLive()
+	if got := s.LiveOverride(); got != LiveOverrideOff {
+		t.Errorf("lor = %d for a new stream, want 0", got)
+	}
+}
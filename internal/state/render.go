@@ -0,0 +1,122 @@
+package state
+
+import (
+	"image/color"
+	"time"
+)
+
+// RenderedLEDs returns the colours as they would appear on the strip, with
+// segment effects, power, global and segment brightness (dithered if set),
+// the channel cap, idle blackout, segment on/off and colour temperature, the
+// overlay and the display calibration applied, or all white while an identify
+// flash is lit.
+// Stored colours are unchanged.
+func (s *LEDState) RenderedLEDs() []color.RGBA {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	black := color.RGBA{A: 255}
+	out := make([]color.RGBA, len(s.leds))
+	if s.identifying {
+		for i := range out {
+			out[i] = color.RGBA{255, 255, 255, 255}
+		}
+		return out
+	}
+	if !s.power || s.blackout {
+		for i := range out {
+			out[i] = black
+		}
+	} else {
+		copy(out, s.leds)
+		s.applyEffects(out, time.Now())
+		for i, c := range out {
+			out[i] = capColor(s.scaleLED(c, s.brightness, i), s.channelCap)
+		}
+		for _, seg := range s.segments {
+			for i := seg.Start; i < seg.Stop && i < len(out); i++ {
+				if !seg.On {
+					out[i] = black
+				} else if bri := seg.Brightness(); bri < 255 {
+					out[i] = s.scaleLED(out[i], bri, i)
+				}
+			}
+			if cct := seg.ColorTemp(); seg.On && cct != NeutralCCT {
+				tint := cctTint(cct)
+				for i := seg.Start; i < seg.Stop && i < len(out); i++ {
+					out[i] = tintColor(out[i], tint)
+				}
+			}
+		}
+	}
+
+	// The overlay shows even when the output is off, so a status tint is
+	// never hidden
+	s.blendOverlay(out)
+
+	if s.calibration != nil {
+		for i, c := range out {
+			out[i] = s.calibration.apply(c)
+		}
+	}
+	return out
+}
+
+// scaleColor scales each channel by brightness (0-255)
+func scaleColor(c color.RGBA, brightness int) color.RGBA {
+	return color.RGBA{
+		R: uint8(int(c.R) * brightness / 255),
+		G: uint8(int(c.G) * brightness / 255),
+		B: uint8(int(c.B) * brightness / 255),
+		A: c.A,
+	}
+}
+
+// Tints at the ends of the cct range, roughly 2700K and 6500K white points
+var (
+	warmTint = color.RGBA{255, 170, 90, 255}
+	coolTint = color.RGBA{205, 225, 255, 255}
+)
+
+// cctTint returns the per-channel multiplier for a segment cct, fading from
+// warmTint at 0 through white at NeutralCCT to coolTint at 255
+func cctTint(cct int) color.RGBA {
+	cct = max(0, min(cct, 255))
+	white := color.RGBA{255, 255, 255, 255}
+	if cct < NeutralCCT {
+		return lerpColor(warmTint, white, cct, NeutralCCT)
+	}
+	return lerpColor(white, coolTint, cct-NeutralCCT, 255-NeutralCCT)
+}
+
+// lerpColor blends from a to b by n/d
+func lerpColor(a, b color.RGBA, n, d int) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(int(x) + (int(y)-int(x))*n/d)
+	}
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 255}
+}
+
+// tintColor multiplies each channel of c by the matching channel of tint
+func tintColor(c, tint color.RGBA) color.RGBA {
+	return color.RGBA{
+		R: uint8(int(c.R) * int(tint.R) / 255),
+		G: uint8(int(c.G) * int(tint.G) / 255),
+		B: uint8(int(c.B) * int(tint.B) / 255),
+		A: c.A,
+	}
+}
+
+// SetChannelCap limits every rendered channel to at most limit, applied as
+// the last step after brightness scaling. 255 disables the cap.
+func (s *LEDState) SetChannelCap(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.channelCap = max(0, min(limit, 255))
+}
+
+// capColor clamps each channel to limit
+func capColor(c color.RGBA, limit int) color.RGBA {
+	m := uint8(limit)
+	return color.RGBA{R: min(c.R, m), G: min(c.G, m), B: min(c.B, m), A: c.A}
+}
@@ -0,0 +1,74 @@
+package state
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestChannelCap(t *testing.T) {
+	state := NewLEDState(2, "#FFFFFF")
+	state.SetLED(1, color.RGBA{100, 255, 150, 255})
+	state.SetChannelCap(200)
+
+	rendered := state.RenderedLEDs()
+	if want := (color.RGBA{200, 200, 200, 255}); rendered[0] != want {
+		t.Errorf("rendered[0] = %v, want %v", rendered[0], want)
+	}
+	// Channels under the cap are unchanged
+	if want := (color.RGBA{100, 200, 150, 255}); rendered[1] != want {
+		t.Errorf("rendered[1] = %v, want %v", rendered[1], want)
+	}
+	// Stored colours are untouched
+	if got := state.LEDs()[0]; got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("stored LED 0 = %v, want white", got)
+	}
+
+	// The cap applies after brightness scaling
+	state.SetBrightness(128)
+	if got := state.RenderedLEDs()[0]; got != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("rendered at brightness 128 = %v, want {128 128 128 255}", got)
+	}
+}
+
+func TestSegmentBrightness(t *testing.T) {
+	state := NewLEDState(4, "#FFFFFF")
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 2, On: true})
+	state.SetSegment(1, Segment{ID: 1, Start: 2, Stop: 4, On: true, Bri: intPtr(51)})
+	state.SetBrightness(128)
+
+	rendered := state.RenderedLEDs()
+	if want := (color.RGBA{128, 128, 128, 255}); rendered[0] != want {
+		t.Errorf("rendered[0] = %v, want %v", rendered[0], want)
+	}
+	// Segment brightness scales on top of the global brightness
+	if want := (color.RGBA{25, 25, 25, 255}); rendered[2] != want {
+		t.Errorf("rendered[2] = %v, want %v", rendered[2], want)
+	}
+}
+
+func TestSegmentCCT(t *testing.T) {
+	state := NewLEDState(4, "#FFFFFF")
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 2, On: true, CCT: intPtr(0)})
+	state.SetSegment(1, Segment{ID: 1, Start: 2, Stop: 4, On: true})
+
+	rendered := state.RenderedLEDs()
+	// The warm extreme keeps red and pulls blue down the most
+	if c := rendered[0]; c != warmTint {
+		t.Errorf("warm rendered[0] = %v, want %v", c, warmTint)
+	}
+	if c := rendered[2]; c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("neutral rendered[2] = %v, want untinted white", c)
+	}
+
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 2, On: true, CCT: intPtr(255)})
+	if c := state.RenderedLEDs()[0]; c.B != 255 || c.R >= 255 {
+		t.Errorf("cool rendered[0] = %v, want blue kept and red reduced", c)
+	}
+	// Stored colours are untouched
+	if c := state.LEDs()[0]; c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("stored LED 0 = %v, want white", c)
+	}
+}
+
+// intPtr returns a pointer to n, for optional segment fields
+func intPtr(n int) *int { return &n }
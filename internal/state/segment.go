@@ -0,0 +1,163 @@
+package state
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+)
+
+// Segment is a contiguous range of LEDs that can be controlled independently
+type Segment struct {
+	ID    int        `json:"id"`
+	Start int        `json:"start"` // First LED index (inclusive)
+	Stop  int        `json:"stop"`  // Last LED index (exclusive)
+	On    bool       `json:"on"`
+	Bri   *int       `json:"bri"` // Segment brightness (0-255) on top of the global brightness, nil for 255
+	CCT   *int       `json:"cct"` // Colour temperature, 0 warm to 255 cool; nil or NeutralCCT leaves colours untinted
+	Rev   bool       `json:"rev"` // Reversed: writes are mirrored within the range
+	Frz   bool       `json:"frz"` // Frozen: LED writes within the range are ignored
+	FX    int        `json:"fx"`  // Effect id, FXSolid shows the stored colours
+	Pal   int        `json:"pal"` // Palette index into Palettes sampled by the effect
+	Col   color.RGBA `json:"col"` // Primary colour last applied to the whole segment
+	// Custom palette the effect samples instead of Pal, when set
+	CPal Gradient `json:"cpal"`
+}
+
+// NeutralCCT is the segment colour temperature that renders without a tint
+const NeutralCCT = 127
+
+// Brightness returns the segment brightness, 255 when none has been set.
+// Leaving Bri nil keeps segments created without one, and scenes saved
+// before it existed, at full brightness rather than black.
+func (seg Segment) Brightness() int {
+	if seg.Bri == nil {
+		return 255
+	}
+	return *seg.Bri
+}
+
+// ColorTemp returns the segment colour temperature, NeutralCCT when none has
+// been set
+func (seg Segment) ColorTemp() int {
+	if seg.CCT == nil {
+		return NeutralCCT
+	}
+	return *seg.CCT
+}
+
+// Len returns the number of LEDs covered by the segment
+func (seg Segment) Len() int {
+	return seg.Stop - seg.Start
+}
+
+// Segments returns a copy of the segment list
+func (s *LEDState) Segments() []Segment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return cloneSegments(s.segments)
+}
+
+// cloneSegments copies segs along with their custom palettes, so neither
+// copy can change the other through a shared CPal
+func cloneSegments(segs []Segment) []Segment {
+	out := make([]Segment, len(segs))
+	for i, seg := range segs {
+		seg.CPal = slices.Clone(seg.CPal)
+		out[i] = seg
+	}
+	return out
+}
+
+// SetMainSegment selects, by id, the segment that API commands without an
+// explicit segment apply to, like WLED's mainseg
+func (s *LEDState) SetMainSegment(id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, seg := range s.segments {
+		if seg.ID == id {
+			s.mainSeg = id
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid mainseg %d. No segment has that id", id)
+}
+
+// MainSegment returns the id of the main segment. If it has been removed,
+// the first segment takes over.
+func (s *LEDState) MainSegment() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, seg := range s.segments {
+		if seg.ID == s.mainSeg {
+			return s.mainSeg
+		}
+	}
+	if len(s.segments) > 0 {
+		return s.segments[0].ID
+	}
+	return 0
+}
+
+// SetSegment replaces the segment at index i, or appends it when i equals the
+// current segment count. The range must lie within the LED buffer.
+func (s *LEDState) SetSegment(i int, seg Segment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i < 0 || i > len(s.segments) {
+		return fmt.Errorf("segment index %d out of range (have %d segments)", i, len(s.segments))
+	}
+	if err := s.checkSegmentRange(seg); err != nil {
+		return err
+	}
+
+	if i == len(s.segments) {
+		s.segments = append(s.segments, seg)
+	} else {
+		s.startEffectFade(s.segments[i], seg)
+		s.segments[i] = seg
+	}
+	return nil
+}
+
+// SetSegmentByID replaces the segment with the same ID, or appends it when no
+// segment has that ID yet. The range must lie within the LED buffer.
+func (s *LEDState) SetSegmentByID(seg Segment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if seg.ID < 0 {
+		return fmt.Errorf("segment id %d must not be negative", seg.ID)
+	}
+	if err := s.checkSegmentRange(seg); err != nil {
+		return err
+	}
+
+	for i := range s.segments {
+		if s.segments[i].ID == seg.ID {
+			s.startEffectFade(s.segments[i], seg)
+			s.segments[i] = seg
+			return nil
+		}
+	}
+	s.segments = append(s.segments, seg)
+	return nil
+}
+
+// checkSegmentRange validates seg against the LED buffer. Callers hold mu.
+func (s *LEDState) checkSegmentRange(seg Segment) error {
+	if seg.Start < 0 || seg.Stop > len(s.leds) || seg.Start >= seg.Stop {
+		return fmt.Errorf("segment range %d-%d invalid for %d LEDs", seg.Start, seg.Stop, len(s.leds))
+	}
+	return nil
+}
+
+// frozen reports whether LED i lies in a frozen segment. Callers hold mu.
+func (s *LEDState) frozen(i int) bool {
+	for _, seg := range s.segments {
+		if seg.Frz && i >= seg.Start && i < seg.Stop {
+			return true
+		}
+	}
+	return false
+}
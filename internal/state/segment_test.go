@@ -0,0 +1,118 @@
+package state
+
+import (
+	"encoding/json"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultSegmentCoversAllLEDs(t *testing.T) {
+	state := NewLEDState(10, "#000000")
+
+	segs := state.Segments()
+	if len(segs) != 1 {
+		t.Fatalf("expected 1 default segment, got %d", len(segs))
+	}
+	if segs[0].Start != 0 || segs[0].Stop != 10 || !segs[0].On {
+		t.Errorf("unexpected default segment: %+v", segs[0])
+	}
+}
+
+func TestSetSegmentValidatesRange(t *testing.T) {
+	state := NewLEDState(10, "#000000")
+
+	if err := state.SetSegment(0, Segment{Start: 0, Stop: 11, On: true}); err == nil {
+		t.Error("expected error for stop beyond LED count")
+	}
+	if err := state.SetSegment(0, Segment{Start: 5, Stop: 5, On: true}); err == nil {
+		t.Error("expected error for empty range")
+	}
+	if err := state.SetSegment(2, Segment{Start: 0, Stop: 5, On: true}); err == nil {
+		t.Error("expected error for index past end of segment list")
+	}
+	if err := state.SetSegment(1, Segment{ID: 1, Start: 5, Stop: 10, On: true}); err != nil {
+		t.Errorf("expected append to succeed: %v", err)
+	}
+	if len(state.Segments()) != 2 {
+		t.Errorf("expected 2 segments after append, got %d", len(state.Segments()))
+	}
+}
+
+func TestSetSegmentByID(t *testing.T) {
+	state := NewLEDState(10, "#000000")
+
+	// A new id is appended
+	if err := state.SetSegmentByID(Segment{ID: 2, Start: 5, Stop: 10, On: true}); err != nil {
+		t.Fatalf("SetSegmentByID failed: %v", err)
+	}
+	// An existing id is replaced in place
+	if err := state.SetSegmentByID(Segment{ID: 2, Start: 6, Stop: 10, On: false}); err != nil {
+		t.Fatalf("SetSegmentByID failed: %v", err)
+	}
+
+	segs := state.Segments()
+	if len(segs) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(segs))
+	}
+	if !reflect.DeepEqual(segs[1], Segment{ID: 2, Start: 6, Stop: 10, On: false}) {
+		t.Errorf("segment id 2 = %+v", segs[1])
+	}
+
+	if err := state.SetSegmentByID(Segment{ID: -1, Start: 0, Stop: 5}); err == nil {
+		t.Error("expected error for negative id")
+	}
+}
+
+func TestRenderedLEDs(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	state := NewLEDState(4, "#FF0000")
+
+	// Brightness scales each channel
+	state.SetBrightness(128)
+	if got := state.RenderedLEDs()[0]; got != (color.RGBA{128, 0, 0, 255}) {
+		t.Errorf("rendered at brightness 128 = %v, want {128 0 0 255}", got)
+	}
+	state.SetBrightness(255)
+
+	// A segment that is off renders black but keeps its stored colour
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 2, On: false})
+	state.SetSegment(1, Segment{ID: 1, Start: 2, Stop: 4, On: true})
+	rendered := state.RenderedLEDs()
+	for i, want := range []color.RGBA{{0, 0, 0, 255}, {0, 0, 0, 255}, red, red} {
+		if rendered[i] != want {
+			t.Errorf("rendered[%d] = %v, want %v", i, rendered[i], want)
+		}
+	}
+	if state.LEDs()[0] != red {
+		t.Errorf("stored colour changed to %v, want %v", state.LEDs()[0], red)
+	}
+
+	// Global power off blanks everything
+	state.SetPower(false)
+	for i, c := range state.RenderedLEDs() {
+		if c != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("rendered[%d] = %v with power off, want black", i, c)
+		}
+	}
+}
+
+func TestSegmentDefaultsWhenMissing(t *testing.T) {
+	// A scene saved before segments had a brightness or colour temperature
+	var seg Segment
+	if err := json.Unmarshal([]byte(`{"id":0,"start":0,"stop":4,"on":true}`), &seg); err != nil {
+		t.Fatal(err)
+	}
+	if got := seg.Brightness(); got != 255 {
+		t.Errorf("Brightness() = %d, want 255 when bri is missing", got)
+	}
+	if got := seg.ColorTemp(); got != NeutralCCT {
+		t.Errorf("ColorTemp() = %d, want NeutralCCT when cct is missing", got)
+	}
+
+	state := NewLEDState(4, "#FFFFFF")
+	state.SetSegment(0, seg)
+	if got := state.RenderedLEDs()[0]; got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("rendered = %v, want untinted full white", got)
+	}
+}
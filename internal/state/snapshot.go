@@ -0,0 +1,106 @@
+package state
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+)
+
+// Snapshot captures the user-visible state so it can be restored later
+type Snapshot struct {
+	On         bool         `json:"on"`
+	Brightness int          `json:"bri"`
+	LEDs       []color.RGBA `json:"leds"`
+	Segments   []Segment    `json:"seg"`
+}
+
+// Snapshot returns a copy of the current power, brightness, colours and segments
+func (s *LEDState) Snapshot() Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := Snapshot{
+		On:         s.power,
+		Brightness: s.brightness,
+		LEDs:       make([]color.RGBA, len(s.leds)),
+		Segments:   cloneSegments(s.segments),
+	}
+	copy(snap.LEDs, s.leds)
+	return snap
+}
+
+// Restore applies a snapshot taken from a state with the same LED count
+func (s *LEDState) Restore(snap Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(snap.LEDs) != len(s.leds) {
+		return fmt.Errorf("snapshot has %d LEDs, expected %d", len(snap.LEDs), len(s.leds))
+	}
+	for _, seg := range snap.Segments {
+		if err := s.checkSegmentRange(seg); err != nil {
+			return err
+		}
+	}
+
+	s.fadeGen++ // Cancel any transition in progress
+	s.blackout = false
+	s.power = snap.On
+	s.brightness = max(0, min(snap.Brightness, 255))
+	copy(s.leds, snap.LEDs)
+	now := time.Now()
+	for i := range s.written {
+		s.written[i] = now
+	}
+	s.segments = cloneSegments(snap.Segments)
+	return nil
+}
+
+// SegmentLayout captures the segment configuration without any pixel data,
+// so segment layouts can be tried out and swapped back independently
+type SegmentLayout struct {
+	MainSeg  int       `json:"mainseg"`
+	Segments []Segment `json:"seg"`
+}
+
+// SaveSegments returns a copy of the segments and the main segment id
+func (s *LEDState) SaveSegments() SegmentLayout {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return SegmentLayout{MainSeg: s.mainSeg, Segments: cloneSegments(s.segments)}
+}
+
+// RestoreSegments replaces every segment with those in layout, leaving the
+// LED colours alone. The layout needs at least one segment, unique
+// non-negative ids, ranges within the LED buffer and a main segment among
+// them.
+func (s *LEDState) RestoreSegments(layout SegmentLayout) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(layout.Segments) == 0 {
+		return fmt.Errorf("segment layout has no segments")
+	}
+	ids := make(map[int]bool, len(layout.Segments))
+	for _, seg := range layout.Segments {
+		if seg.ID < 0 {
+			return fmt.Errorf("segment id %d must not be negative", seg.ID)
+		}
+		if ids[seg.ID] {
+			return fmt.Errorf("segment id %d is used more than once", seg.ID)
+		}
+		ids[seg.ID] = true
+		if err := s.checkSegmentRange(seg); err != nil {
+			return err
+		}
+	}
+	if !ids[layout.MainSeg] {
+		return fmt.Errorf("invalid mainseg %d. No segment has that id", layout.MainSeg)
+	}
+
+	s.segments = cloneSegments(layout.Segments)
+	s.mainSeg = layout.MainSeg
+	s.fxFades = nil // Fades belong to the segments being replaced
+	return nil
+}
@@ -0,0 +1,104 @@
+package state
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	state := NewLEDState(4, "#FF0000")
+	state.SetBrightness(100)
+	snap := state.Snapshot()
+
+	state.SetBrightness(10)
+	state.SetPower(false)
+	state.SetLED(0, color.RGBA{0, 0, 255, 255})
+	state.SetSegment(1, Segment{ID: 1, Start: 2, Stop: 4, On: true})
+
+	if err := state.Restore(snap); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if state.Brightness() != 100 || !state.Power() {
+		t.Errorf("restored bri=%d on=%v, want 100 true", state.Brightness(), state.Power())
+	}
+	if got := state.LEDs()[0]; got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("restored LED 0 = %v, want red", got)
+	}
+	if n := len(state.Segments()); n != 1 {
+		t.Errorf("restored %d segments, want 1", n)
+	}
+
+	// Snapshots from a different strip length are rejected
+	other := NewLEDState(2, "#000000").Snapshot()
+	if err := state.Restore(other); err == nil {
+		t.Error("expected error restoring a snapshot with a different LED count")
+	}
+}
+
+func TestSaveRestoreSegments(t *testing.T) {
+	state := NewLEDState(10, "#000000")
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 4, On: true, Bri: intPtr(128), Rev: true})
+	state.SetSegment(1, Segment{ID: 3, Start: 4, Stop: 10, On: false, FX: FXGradient})
+	if err := state.SetMainSegment(3); err != nil {
+		t.Fatal(err)
+	}
+	saved := state.SaveSegments()
+
+	// Clear back to a single segment, with some pixel data written after
+	if err := state.RestoreSegments(SegmentLayout{Segments: []Segment{{ID: 0, Start: 0, Stop: 10, On: true}}}); err != nil {
+		t.Fatalf("RestoreSegments failed: %v", err)
+	}
+	if n := len(state.Segments()); n != 1 {
+		t.Fatalf("cleared to %d segments, want 1", n)
+	}
+	state.SetLED(5, color.RGBA{0, 255, 0, 255})
+
+	if err := state.RestoreSegments(saved); err != nil {
+		t.Fatalf("RestoreSegments failed: %v", err)
+	}
+	if got := state.SaveSegments(); !reflect.DeepEqual(got, saved) {
+		t.Errorf("restored %+v, want %+v", got, saved)
+	}
+	if got := state.LEDs()[5]; got != (color.RGBA{0, 255, 0, 255}) {
+		t.Errorf("LED 5 = %v, want pixel data left alone", got)
+	}
+
+	for name, layout := range map[string]SegmentLayout{
+		"empty":        {},
+		"duplicate id": {Segments: []Segment{{ID: 1, Start: 0, Stop: 5}, {ID: 1, Start: 5, Stop: 10}}},
+		"out of range": {Segments: []Segment{{ID: 0, Start: 0, Stop: 11}}},
+		"no mainseg":   {MainSeg: 2, Segments: []Segment{{ID: 0, Start: 0, Stop: 10}}},
+	} {
+		if err := state.RestoreSegments(layout); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if got := state.SaveSegments(); !reflect.DeepEqual(got, saved) {
+		t.Errorf("rejected layouts changed the segments to %+v", got)
+	}
+}
+
+func TestSegmentCopiesOwnCPal(t *testing.T) {
+	red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+	state := NewLEDState(4, "#000000")
+	state.SetSegment(0, Segment{ID: 0, Start: 0, Stop: 4, On: true, CPal: Gradient{red, red}})
+
+	// Writing to any copy handed out must leave the live segment alone
+	state.Segments()[0].CPal[0] = blue
+	state.Snapshot().Segments[0].CPal[0] = blue
+	layout := state.SaveSegments()
+	layout.Segments[0].CPal[0] = blue
+	if got := state.Segments()[0].CPal[0]; got != red {
+		t.Fatalf("live cpal = %v after writing to copies, want %v", got, red)
+	}
+
+	// Nor may the layout it was restored from change it afterwards
+	if err := state.RestoreSegments(layout); err != nil {
+		t.Fatalf("RestoreSegments failed: %v", err)
+	}
+	layout.Segments[0].CPal[0] = red
+	if got := state.Segments()[0].CPal[0]; got != blue {
+		t.Errorf("live cpal = %v after writing to the restored layout, want %v", got, blue)
+	}
+}
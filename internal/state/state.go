@@ -14,6 +14,13 @@ const (
 	ActivityDDP
 )
 
+func (t ActivityType) String() string {
+	if t == ActivityDDP {
+		return "ddp"
+	}
+	return "json"
+}
+
 type ActivityEvent struct {
 	Type      ActivityType
 	Success   bool
@@ -23,27 +30,80 @@ type ActivityEvent struct {
 type LEDState struct {
 	mu              sync.RWMutex
 	power           bool
-	brightness      int // 0-255
+	brightness      int           // 0-255
+	transition      time.Duration // Default brightness transition duration
+	fadeGen         int           // Incremented to cancel a running transition
+	nightlight      Nightlight    // Nightlight settings; On while the timer runs
+	nlDeadline      time.Time     // When the running nightlight ends
+	nlGen           int           // Incremented to cancel a running nightlight
+	identifying     bool          // Identify flash lit, overriding the output
+	identifyGen     int           // Incremented to cancel a running identify
+	channelCap      int           // Max rendered value per channel, 255 for none
+	dither          bool          // Ordered dither when scaling brightness
+	calibration     *Calibration  // Per-channel display curves, nil for identity
 	leds            []color.RGBA
-	lastLiveTime    time.Time          // Timestamp of last DDP packet received
+	written         []time.Time        // When each LED was last written
+	white           []uint8            // Separate white channel of RGBW LEDs
+	rgbw            bool               // LEDs are RGBW, with white always kept apart
+	onFrame         func([]color.RGBA) // Called with each committed frame
+	overlay         []color.RGBA       // Blended over the rendered output, nil for none
+	segments        []Segment
+	mainSeg         int                // Segment id targeted when none is given
+	fxFades         map[int]fxFade     // Effect cross-fades, keyed by segment ID
+	lastLiveTime    time.Time          // Timestamp of last DDP packet carrying pixels
+	lastPacketTime  time.Time          // Timestamp of last DDP packet of any kind
+	lastSource      string             // Address of the last DDP sender
+	blackout        bool               // Output blanked after live data stopped
 	liveTimeout     time.Duration      // How long to consider live after last packet
+	liveDebounce    time.Duration      // Min time between live timestamp updates
+	liveOverride    int                // WLED lor: whether live data or other changes win
 	activityChannel chan ActivityEvent // Channel for activity events
+	subscribers     map[chan ActivityEvent]struct{}
+	subsMu          sync.Mutex // Protect subscribers
+}
+
+// DefaultActivityBuffer is how many activity events are queued for the GUI
+// before new ones are dropped
+const DefaultActivityBuffer = 100
+
+// Option configures a LEDState at construction
+type Option func(*LEDState)
+
+// WithActivityBuffer sets the activity channel capacity, at least 1
+func WithActivityBuffer(size int) Option {
+	return func(s *LEDState) {
+		s.activityChannel = make(chan ActivityEvent, max(size, 1))
+	}
 }
 
 // NewLEDState constructs a LEDState with n LEDs initialized to hex colour
-func NewLEDState(n int, hex string) *LEDState {
+func NewLEDState(n int, hex string, opts ...Option) *LEDState {
 	leds := make([]color.RGBA, n)
 	c := parseHex(hex)
 	for i := range leds {
 		leds[i] = c
 	}
-	return &LEDState{
+	written := make([]time.Time, n)
+	now := time.Now()
+	for i := range written {
+		written[i] = now
+	}
+	s := &LEDState{
 		power:           true,
 		brightness:      255,
+		channelCap:      255,
+		nightlight:      DefaultNightlight,
 		leds:            leds,
-		liveTimeout:     5 * time.Second,               // Consider live for 5 seconds after last packet
-		activityChannel: make(chan ActivityEvent, 100), // Buffered channel for activity events
+		written:         written,
+		white:           make([]uint8, n),
+		segments:        []Segment{{ID: 0, Start: 0, Stop: n, On: true, Col: c}},
+		liveTimeout:     5 * time.Second,                                 // Consider live for 5 seconds after last packet
+		activityChannel: make(chan ActivityEvent, DefaultActivityBuffer), // Buffered channel for activity events
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // parseHex converts "#RRGGBB" to color.RGBA
@@ -60,6 +120,7 @@ func (s *LEDState) SetPower(on bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.power = on
+	s.blackout = false
 }
 
 func (s *LEDState) Power() bool {
@@ -77,7 +138,9 @@ func (s *LEDState) SetBrightness(b int) {
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.fadeGen++ // Cancel any transition in progress
 	s.brightness = b
+	s.blackout = false
 }
 
 func (s *LEDState) Brightness() int {
@@ -89,9 +152,98 @@ func (s *LEDState) Brightness() int {
 func (s *LEDState) SetLED(i int, c color.RGBA) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if i >= 0 && i < len(s.leds) {
+	if i >= 0 && i < len(s.leds) && !s.frozen(i) {
 		s.leds[i] = c
+		s.written[i] = time.Now()
+	}
+}
+
+// SetLEDs replaces the LED buffer with frame, ignoring any entries beyond the
+// LED count and LEDs in frozen segments. A new frame ends an idle blackout.
+func (s *LEDState) SetLEDs(frame []color.RGBA) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blackout = false
+	now := time.Now()
+	for i := 0; i < len(frame) && i < len(s.leds); i++ {
+		if !s.frozen(i) {
+			s.leds[i] = frame[i]
+			s.written[i] = now
+		}
+	}
+}
+
+// SetOnFrame registers fn to be called with a copy of the LEDs each time a
+// frame is committed, such as on a DDP push. fn runs on the committing
+// goroutine without the state locked, so it may read or write the state, but
+// a slow fn holds up the sender's next frame. nil removes the callback.
+func (s *LEDState) SetOnFrame(fn func([]color.RGBA)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFrame = fn
+}
+
+// CommitFrame marks the current LEDs as a complete frame, passing them to the
+// SetOnFrame callback if one is registered
+func (s *LEDState) CommitFrame() {
+	s.mu.RLock()
+	fn := s.onFrame
+	var frame []color.RGBA
+	if fn != nil {
+		frame = make([]color.RGBA, len(s.leds))
+		copy(frame, s.leds)
 	}
+	s.mu.RUnlock()
+
+	if fn != nil {
+		fn(frame)
+	}
+}
+
+// SetWhite stores the white channel of LED i apart from its RGB colour, for
+// RGBW strips with physically separate white LEDs
+func (s *LEDState) SetWhite(i int, w uint8) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i >= 0 && i < len(s.white) && !s.frozen(i) {
+		s.white[i] = w
+	}
+}
+
+// SetRGBW marks the LEDs as RGBW. DDP pixels are then read as 4 bytes with
+// the white byte stored in the white channel, and the GUI shows it as an
+// overlay, whatever the decode options say.
+func (s *LEDState) SetRGBW(on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rgbw = on
+}
+
+// RGBW reports whether the LEDs are RGBW
+func (s *LEDState) RGBW() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rgbw
+}
+
+// White returns a copy of the stored white channel, zero for LEDs that never
+// received one
+func (s *LEDState) White() []uint8 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]uint8, len(s.white))
+	copy(out, s.white)
+	return out
+}
+
+// LastWrites returns when each LED was last written, starting from when the
+// state was created
+func (s *LEDState) LastWrites() []time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]time.Time, len(s.written))
+	copy(out, s.written)
+	return out
 }
 
 func (s *LEDState) LEDs() []color.RGBA {
@@ -102,23 +254,106 @@ func (s *LEDState) LEDs() []color.RGBA {
 	return out
 }
 
-// SetLive marks that DDP data is currently being received
+// SetLive marks that DDP pixel data is currently being received
 func (s *LEDState) SetLive() {
+	// Most packets at high frame rates only need the read lock
+	s.mu.RLock()
+	fresh := !s.blackout && s.debounced(s.lastLiveTime)
+	s.mu.RUnlock()
+	if fresh {
+		return
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if !s.live() && s.liveOverride == LiveOverrideUntilEnd {
+		s.liveOverride = LiveOverrideOff // A new stream after the overridden one ended
+	}
 	s.lastLiveTime = time.Now()
+	s.lastPacketTime = s.lastLiveTime
+	s.blackout = false
+}
+
+// SetConnected marks that a DDP sender is present, even if it is only sending
+// queries or empty heartbeat packets
+func (s *LEDState) SetConnected() {
+	s.mu.RLock()
+	fresh := s.debounced(s.lastPacketTime)
+	s.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastPacketTime = time.Now()
+}
+
+// SetLiveDebounce limits how often SetLive and SetConnected update their
+// timestamps, saving a write lock per packet. The interval is capped at a
+// tenth of the live timeout so liveness stays accurate. Zero updates on every call.
+func (s *LEDState) SetLiveDebounce(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.liveDebounce = max(d, 0)
+}
+
+// debounced reports whether last was updated recently enough to skip another
+// update. Callers hold mu.
+func (s *LEDState) debounced(last time.Time) bool {
+	d := min(s.liveDebounce, s.liveTimeout/10)
+	return d > 0 && !last.IsZero() && time.Since(last) < d
+}
+
+// IsConnected returns true if any DDP packet has been received recently
+func (s *LEDState) IsConnected() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.lastPacketTime.IsZero() {
+		return false
+	}
+	return time.Since(s.lastPacketTime) <= s.liveTimeout
+}
+
+// SetLastSource records the address of the most recent DDP sender
+func (s *LEDState) SetLastSource(addr string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSource = addr
+}
+
+// LastSource returns the most recent DDP sender, or "" once live data has timed out
+func (s *LEDState) LastSource() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.lastLiveTime.IsZero() || time.Since(s.lastLiveTime) > s.liveTimeout {
+		return ""
+	}
+	return s.lastSource
 }
 
 // IsLive returns true if DDP data has been received recently
 func (s *LEDState) IsLive() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
+	return s.live()
+}
+
+// live is IsLive for callers holding mu
+func (s *LEDState) live() bool {
 	if s.lastLiveTime.IsZero() {
 		return false
 	}
 	return time.Since(s.lastLiveTime) <= s.liveTimeout
 }
 
+// LiveTimeout returns how long the device stays live after the last packet
+func (s *LEDState) LiveTimeout() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.liveTimeout
+}
+
 // SetLiveTimeout sets the duration for which the device should be considered live after receiving data
 func (s *LEDState) SetLiveTimeout(timeout time.Duration) {
 	s.mu.Lock()
@@ -141,6 +376,33 @@ func (s *LEDState) ReportActivity(activityType ActivityType, success bool) {
 	default:
 		// Channel is full, drop the event
 	}
+
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default: // Slow subscriber, drop the event
+		}
+	}
+}
+
+// SubscribeActivity returns a channel receiving a copy of every activity
+// event, alongside ActivityChannel, and a function to unsubscribe. Events
+// are dropped while size are waiting unread.
+func (s *LEDState) SubscribeActivity(size int) (<-chan ActivityEvent, func()) {
+	ch := make(chan ActivityEvent, max(size, 1))
+	s.subsMu.Lock()
+	defer s.subsMu.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan ActivityEvent]struct{})
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch, func() {
+		s.subsMu.Lock()
+		defer s.subsMu.Unlock()
+		delete(s.subscribers, ch)
+	}
 }
 
 // ActivityChannel returns the activity event channel for consumers
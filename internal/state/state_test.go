@@ -1,6 +1,7 @@
 package state
 
 import (
+	"image/color"
 	"testing"
 	"time"
 )
@@ -62,3 +63,139 @@ func TestLiveTimeout(t *testing.T) {
 		t.Error("Expected IsLive() to be false after short timeout")
 	}
 }
+
+func TestLastSourceClearsAfterTimeout(t *testing.T) {
+	state := NewLEDState(10, "#000000")
+	state.SetLiveTimeout(50 * time.Millisecond)
+
+	state.SetLive()
+	state.SetLastSource("192.168.1.20:4048")
+	if got := state.LastSource(); got != "192.168.1.20:4048" {
+		t.Errorf("LastSource() = %q, want %q", got, "192.168.1.20:4048")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if got := state.LastSource(); got != "" {
+		t.Errorf("LastSource() = %q after timeout, want empty", got)
+	}
+}
+
+func TestActivityBuffer(t *testing.T) {
+	if got := cap(NewLEDState(1, "#000000").ActivityChannel()); got != DefaultActivityBuffer {
+		t.Errorf("default capacity = %d, want %d", got, DefaultActivityBuffer)
+	}
+	if got := cap(NewLEDState(1, "#000000", WithActivityBuffer(500)).ActivityChannel()); got != 500 {
+		t.Errorf("capacity = %d, want 500", got)
+	}
+	if got := cap(NewLEDState(1, "#000000", WithActivityBuffer(0)).ActivityChannel()); got != 1 {
+		t.Errorf("capacity for 0 = %d, want minimum 1", got)
+	}
+}
+
+func TestLastWrites(t *testing.T) {
+	state := NewLEDState(3, "#000000")
+	created := state.LastWrites()
+	if len(created) != 3 || created[0].IsZero() {
+		t.Fatalf("expected creation timestamps, got %v", created)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	state.SetLED(1, color.RGBA{255, 0, 0, 255})
+	writes := state.LastWrites()
+	if !writes[1].After(created[1]) {
+		t.Errorf("LED 1 write time %v not after creation %v", writes[1], created[1])
+	}
+	if !writes[0].Equal(created[0]) || !writes[2].Equal(created[2]) {
+		t.Error("expected untouched LEDs to keep their timestamps")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	state.SetLEDs([]color.RGBA{{0, 255, 0, 255}})
+	if again := state.LastWrites(); !again[0].After(writes[0]) || !again[1].Equal(writes[1]) {
+		t.Errorf("SetLEDs should only update LED 0, got %v", again)
+	}
+}
+
+func TestRGBWStoresWhite(t *testing.T) {
+	s := NewLEDState(3, "#000000")
+	if s.RGBW() {
+		t.Fatal("expected RGB by default")
+	}
+	s.SetRGBW(true)
+	if !s.RGBW() {
+		t.Fatal("expected RGBW after SetRGBW(true)")
+	}
+
+	s.SetLED(1, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	s.SetWhite(1, 200)
+	s.SetWhite(5, 99) // Out of range, ignored
+
+	if got := s.White(); got[0] != 0 || got[1] != 200 || got[2] != 0 {
+		t.Errorf("White = %v, want [0 200 0]", got)
+	}
+	if got, want := s.LEDs()[1], (color.RGBA{R: 10, G: 20, B: 30, A: 255}); got != want {
+		t.Errorf("LED 1 = %v, want %v with white kept apart", got, want)
+	}
+}
+
+func TestLiveDebounce(t *testing.T) {
+	state := NewLEDState(1, "#000000")
+	state.SetLiveTimeout(200 * time.Millisecond)
+	state.SetLiveDebounce(time.Second) // Capped at 20ms by the timeout
+
+	state.SetLive()
+	first := state.lastLiveTime
+	state.SetLive()
+	if !state.lastLiveTime.Equal(first) {
+		t.Error("expected a second SetLive within the debounce not to update the timestamp")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	state.SetLive()
+	if !state.lastLiveTime.After(first) {
+		t.Error("expected SetLive after the capped debounce to update the timestamp")
+	}
+
+	// Keep sending for longer than the timeout; liveness must not lapse
+	for i := 0; i < 30; i++ {
+		state.SetLive()
+		if !state.IsLive() {
+			t.Fatalf("lost liveness while packets were arriving (iteration %d)", i)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	time.Sleep(250 * time.Millisecond)
+	if state.IsLive() {
+		t.Error("expected not live after the timeout")
+	}
+
+	// A blackout is cleared by the next packet even within the debounce
+	state.SetLive()
+	state.mu.Lock()
+	state.blackout = true
+	state.mu.Unlock()
+	state.SetLive()
+	if state.Blackout() {
+		t.Error("expected SetLive to clear the blackout")
+	}
+}
+
+func benchmarkSetLive(b *testing.B, debounce time.Duration) {
+	state := NewLEDState(1, "#000000")
+	state.SetLiveDebounce(debounce)
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			state.SetLive()
+		}
+	})
+}
+
+// Compare with BenchmarkSetLiveDebounced to see the write locks saved
+func BenchmarkSetLive(b *testing.B) {
+	benchmarkSetLive(b, 0)
+}
+
+func BenchmarkSetLiveDebounced(b *testing.B) {
+	benchmarkSetLive(b, 50*time.Millisecond)
+}
@@ -0,0 +1,73 @@
+package state
+
+import "time"
+
+// transitionStep is how often brightness is updated during a transition
+const transitionStep = 10 * time.Millisecond
+
+// SetTransition sets the default duration of brightness transitions
+func (s *LEDState) SetTransition(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transition = d
+}
+
+// Transition returns the default duration of brightness transitions
+func (s *LEDState) Transition() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.transition
+}
+
+// TransitionBrightness fades brightness to b over d, replacing any transition
+// already in progress. A zero duration applies the change immediately.
+func (s *LEDState) TransitionBrightness(b int, d time.Duration) {
+	if b < 0 {
+		b = 0
+	}
+	if b > 255 {
+		b = 255
+	}
+
+	s.mu.Lock()
+	s.fadeGen++
+	s.blackout = false
+	gen := s.fadeGen
+	from := s.brightness
+	if d <= 0 {
+		s.brightness = b
+		s.mu.Unlock()
+		return
+	}
+	s.mu.Unlock()
+
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(transitionStep)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			elapsed := time.Since(start)
+			value := b
+			if elapsed < d {
+				value = from + int(float64(b-from)*float64(elapsed)/float64(d))
+			}
+
+			s.mu.Lock()
+			if s.fadeGen != gen {
+				// Superseded by a newer brightness change
+				s.mu.Unlock()
+				return
+			}
+			s.brightness = value
+			s.mu.Unlock()
+
+			if elapsed >= d {
+				return
+			}
+		}
+	}()
+}
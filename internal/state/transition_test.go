@@ -0,0 +1,35 @@
+package state
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransitionBrightness(t *testing.T) {
+	s := NewLEDState(1, "#000000")
+	s.SetBrightness(0)
+
+	s.TransitionBrightness(100, 200*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	if b := s.Brightness(); b <= 0 || b >= 100 {
+		t.Errorf("brightness = %d halfway through, want between 0 and 100", b)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if b := s.Brightness(); b != 100 {
+		t.Errorf("brightness = %d after transition, want 100", b)
+	}
+}
+
+func TestTransitionBrightnessSuperseded(t *testing.T) {
+	s := NewLEDState(1, "#000000")
+	s.SetBrightness(0)
+
+	// A direct change cancels the running fade
+	s.TransitionBrightness(255, 100*time.Millisecond)
+	s.SetBrightness(10)
+	time.Sleep(150 * time.Millisecond)
+	if b := s.Brightness(); b != 10 {
+		t.Errorf("brightness = %d, want 10 after cancelled transition", b)
+	}
+}